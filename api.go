@@ -0,0 +1,70 @@
+package revenuecat
+
+import (
+	"context"
+	"time"
+)
+
+// SubscriberReader fetches subscribers.
+type SubscriberReader interface {
+	GetSubscriber(userID string) (Subscriber, error)
+	GetSubscriberContext(ctx context.Context, userID string) (Subscriber, error)
+	GetSubscriberWithPlatform(userID string, platform string) (Subscriber, error)
+	GetSubscriberWithPlatformContext(ctx context.Context, userID string, platform string) (Subscriber, error)
+}
+
+// SubscriberManager updates and deletes subscribers.
+type SubscriberManager interface {
+	UpdateSubscriberAttributes(userID string, attributes map[string]SubscriberAttribute) error
+	UpdateSubscriberAttributesContext(ctx context.Context, userID string, attributes map[string]SubscriberAttribute) error
+	AddUserAttribution(userID string, network Network, data AttributionData) error
+	AddUserAttributionContext(ctx context.Context, userID string, network Network, data AttributionData) error
+	DeleteSubscriber(userID string) error
+	DeleteSubscriberContext(ctx context.Context, userID string) error
+}
+
+// EntitlementManager grants and revokes promotional entitlements.
+type EntitlementManager interface {
+	GrantEntitlement(userID string, id string, duration Duration, startTime time.Time) (Subscriber, error)
+	GrantEntitlementContext(ctx context.Context, userID string, id string, duration Duration, startTime time.Time) (Subscriber, error)
+	RevokeEntitlement(userID string, id string) (Subscriber, error)
+	RevokeEntitlementContext(ctx context.Context, userID string, id string) (Subscriber, error)
+}
+
+// PurchaseRecorder records purchases from receipts.
+type PurchaseRecorder interface {
+	CreatePurchase(userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error)
+	CreatePurchaseContext(ctx context.Context, userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error)
+}
+
+// OfferingManager overrides the current Offering for subscribers.
+type OfferingManager interface {
+	OverrideOffering(userID string, offeringUUID string) (Subscriber, error)
+	OverrideOfferingContext(ctx context.Context, userID string, offeringUUID string) (Subscriber, error)
+	DeleteOfferingOverride(userID string) (Subscriber, error)
+	DeleteOfferingOverrideContext(ctx context.Context, userID string) (Subscriber, error)
+}
+
+// GoogleSubscriptionManager refunds and defers Google subscriptions.
+type GoogleSubscriptionManager interface {
+	RefundGoogleSubscription(userID string, id string) (Subscriber, error)
+	RefundGoogleSubscriptionContext(ctx context.Context, userID string, id string) (Subscriber, error)
+	DeferGoogleSubscription(userID string, id string, nextExpiry time.Time) (Subscriber, error)
+	DeferGoogleSubscriptionContext(ctx context.Context, userID string, id string, nextExpiry time.Time) (Subscriber, error)
+}
+
+// API is the full set of operations provided by *Client and *CachedClient.
+// Depend on the smaller interfaces where possible, so tests only need to stub what is used.
+type API interface {
+	SubscriberReader
+	SubscriberManager
+	EntitlementManager
+	PurchaseRecorder
+	OfferingManager
+	GoogleSubscriptionManager
+}
+
+var (
+	_ API = (*Client)(nil)
+	_ API = (*CachedClient)(nil)
+)
@@ -1,5 +1,7 @@
 package revenuecat
 
+import "context"
+
 // Network represents a predefined attribution channel.
 type Network int
 
@@ -22,6 +24,11 @@ type AttributionData struct {
 // AddUserAttribution attaches attribution data to a subscriber from specific supported networks.
 // https://docs.revenuecat.com/reference#subscribersattribution
 func (c *Client) AddUserAttribution(userID string, network Network, data AttributionData) error {
+	return c.AddUserAttributionContext(context.Background(), userID, network, data)
+}
+
+// AddUserAttributionContext is like AddUserAttribution but uses ctx for the request.
+func (c *Client) AddUserAttributionContext(ctx context.Context, userID string, network Network, data AttributionData) error {
 	var resp struct {
 		Subscriber Subscriber `json:"subscriber"`
 	}
@@ -34,5 +41,5 @@ func (c *Client) AddUserAttribution(userID string, network Network, data Attribu
 		Network: network,
 	}
 
-	return c.call("POST", "subscribers/"+userID+"/attribution", req, "", &resp)
+	return c.call(ctx, "POST", "subscribers/"+userID+"/attribution", req, "", &resp)
 }
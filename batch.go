@@ -0,0 +1,130 @@
+package revenuecat
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultBatchConcurrency is how many subscribers GetSubscribers fetches at a time by default.
+const defaultBatchConcurrency = 8
+
+// GetSubscribersOptions holds the optional values for fetching many subscribers.
+type GetSubscribersOptions struct {
+	// Concurrency is how many requests run at the same time. Defaults to 8.
+	Concurrency int
+	// Platform is passed to GetSubscriberWithPlatform for every subscriber.
+	Platform string
+}
+
+// SubscriberResult is the result of fetching one subscriber in a batch.
+type SubscriberResult struct {
+	UserID     string
+	Subscriber Subscriber
+	Err        error
+}
+
+// GetSubscribers fetches the subscribers for userIDs with GetSubscriberWithPlatform, running up to
+// opt.Concurrency requests at a time. Results are returned in the order of userIDs, each with its own error.
+// If ctx is cancelled, subscribers that weren't fetched have ctx's error.
+func (c *Client) GetSubscribers(ctx context.Context, userIDs []string, opt *GetSubscribersOptions) []SubscriberResult {
+	return getSubscribers(ctx, c, userIDs, opt)
+}
+
+// StreamSubscribers is like GetSubscribers but sends each result on the returned channel as soon as it is
+// fetched, in no particular order, so the results don't need to be held in memory. The channel is closed
+// when every subscriber has been sent or ctx is cancelled. Callers must receive until the channel is closed
+// or cancel ctx.
+func (c *Client) StreamSubscribers(ctx context.Context, userIDs []string, opt *GetSubscribersOptions) <-chan SubscriberResult {
+	return streamSubscribers(ctx, c, userIDs, opt)
+}
+
+// GetSubscribers is like Client.GetSubscribers but reads through the cache.
+func (c *CachedClient) GetSubscribers(ctx context.Context, userIDs []string, opt *GetSubscribersOptions) []SubscriberResult {
+	return getSubscribers(ctx, c, userIDs, opt)
+}
+
+// StreamSubscribers is like Client.StreamSubscribers but reads through the cache.
+func (c *CachedClient) StreamSubscribers(ctx context.Context, userIDs []string, opt *GetSubscribersOptions) <-chan SubscriberResult {
+	return streamSubscribers(ctx, c, userIDs, opt)
+}
+
+type indexedResult struct {
+	index int
+	SubscriberResult
+}
+
+func getSubscribers(ctx context.Context, r SubscriberReader, userIDs []string, opt *GetSubscribersOptions) []SubscriberResult {
+	results := make([]SubscriberResult, len(userIDs))
+	done := make([]bool, len(userIDs))
+	for res := range fanOut(ctx, r, userIDs, opt) {
+		results[res.index] = res.SubscriberResult
+		done[res.index] = true
+	}
+	for i, ok := range done {
+		if !ok {
+			results[i] = SubscriberResult{UserID: userIDs[i], Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+func streamSubscribers(ctx context.Context, r SubscriberReader, userIDs []string, opt *GetSubscribersOptions) <-chan SubscriberResult {
+	out := make(chan SubscriberResult)
+	go func() {
+		defer close(out)
+		for res := range fanOut(ctx, r, userIDs, opt) {
+			select {
+			case out <- res.SubscriberResult:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out
+}
+
+// fanOut fetches userIDs with a bounded pool of workers. It stops handing out IDs when ctx is cancelled,
+// and drops results that can't be delivered once it is.
+func fanOut(ctx context.Context, r SubscriberReader, userIDs []string, opt *GetSubscribersOptions) <-chan indexedResult {
+	if opt == nil {
+		opt = &GetSubscribersOptions{}
+	}
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if concurrency > len(userIDs) {
+		concurrency = len(userIDs)
+	}
+
+	work := make(chan int)
+	out := make(chan indexedResult)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				sub, err := r.GetSubscriberWithPlatformContext(ctx, userIDs[i], opt.Platform)
+				res := indexedResult{i, SubscriberResult{UserID: userIDs[i], Subscriber: sub, Err: err}}
+				select {
+				case out <- res:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		defer wg.Wait()
+		defer close(work)
+		for i := range userIDs {
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
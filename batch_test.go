@@ -0,0 +1,150 @@
+package revenuecat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newBatchTestClient returns a client that answers with the requested user ID, fails for IDs starting
+// with "missing", and tracks the most concurrent requests seen.
+func newBatchTestClient(delay time.Duration) (*Client, *int) {
+	var mu sync.Mutex
+	var running, max int
+	rc := New("apikey")
+	cl := &mockClient{}
+	cl.doer = func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		userID := strings.TrimPrefix(req.URL.Path, "/v1/subscribers/")
+		if strings.HasPrefix(userID, "missing") {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"code":7259,"message":"Subscriber not found."}`)),
+			}, nil
+		}
+		body := fmt.Sprintf(`{"subscriber":{"original_app_user_id":%q}}`, userID)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+	rc.http = cl
+	return rc, &max
+}
+
+func TestGetSubscribers(t *testing.T) {
+	rc, max := newBatchTestClient(5 * time.Millisecond)
+
+	var ids []string
+	for i := 0; i < 20; i++ {
+		ids = append(ids, fmt.Sprintf("user%d", i))
+	}
+	ids = append(ids, "missing1")
+
+	results := rc.GetSubscribers(context.Background(), ids, &GetSubscribersOptions{Concurrency: 3})
+	if len(results) != len(ids) {
+		t.Fatalf("expected %d results, got %d", len(ids), len(results))
+	}
+	for i, res := range results {
+		if res.UserID != ids[i] {
+			t.Errorf("expected results in input order, got %q at %d", res.UserID, i)
+		}
+		if res.UserID == "missing1" {
+			if !IsNotFound(res.Err) {
+				t.Errorf("expected not found error, got %v", res.Err)
+			}
+			continue
+		}
+		if res.Err != nil || res.Subscriber.OriginalAppUserID != ids[i] {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	}
+	if *max > 3 {
+		t.Errorf("expected at most 3 concurrent requests, got %d", *max)
+	}
+}
+
+func TestGetSubscribersCanceled(t *testing.T) {
+	rc, _ := newBatchTestClient(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results := rc.GetSubscribers(ctx, []string{"1", "2", "3"}, &GetSubscribersOptions{Concurrency: 1})
+	for _, res := range results {
+		if !errors.Is(res.Err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", res.Err)
+		}
+	}
+}
+
+func TestStreamSubscribers(t *testing.T) {
+	rc, _ := newBatchTestClient(time.Millisecond)
+
+	seen := make(map[string]bool)
+	for res := range rc.StreamSubscribers(context.Background(), []string{"1", "2", "missing3"}, nil) {
+		if res.UserID != "missing3" && res.Subscriber.OriginalAppUserID != res.UserID {
+			t.Errorf("unexpected result: %+v", res)
+		}
+		seen[res.UserID] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected 3 results, got %v", seen)
+	}
+}
+
+func TestStreamSubscribersStopsOnCancel(t *testing.T) {
+	rc, _ := newBatchTestClient(time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+	results := rc.StreamSubscribers(ctx, ids, &GetSubscribersOptions{Concurrency: 2})
+	<-results
+	cancel()
+
+	n := 0
+	for range results {
+		n++
+	}
+	if n >= len(ids)-1 {
+		t.Errorf("expected streaming to stop after cancel, got %d more results", n)
+	}
+}
+
+func TestCachedClientGetSubscribers(t *testing.T) {
+	cl, count := newCountingClient(t)
+	rc := New("apikey")
+	rc.http = cl
+	cached := NewCachedClient(rc, time.Minute, 10)
+
+	cached.GetSubscribers(context.Background(), []string{"123"}, nil)
+	cached.GetSubscribers(context.Background(), []string{"123"}, nil)
+	if *count != 1 {
+		t.Errorf("expected batch to read through the cache, got %d requests", *count)
+	}
+}
@@ -0,0 +1,230 @@
+package revenuecat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request with a CircuitOpenError.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to detect recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerPolicy configures when the circuit breaker opens and how it recovers.
+// Transport errors, timeouts and 5xx responses count as failures; other errors count as successes,
+// since they show that RevenueCat is responding. Calls that fail after their context is done aren't
+// counted, whether they were waiting on RevenueCat, a rate limit or a retry.
+type CircuitBreakerPolicy struct {
+	// FailureRate is the fraction of failed calls, between 0 and 1, that opens the circuit.
+	FailureRate float64
+	// MinCalls is how many calls a window needs before FailureRate is applied.
+	MinCalls int
+	// Window is how long calls are counted for before the counts are reset.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before letting probe calls through.
+	OpenTimeout time.Duration
+	// Probes is how many calls may run while half-open. The circuit closes once they all succeed
+	// and opens again as soon as one fails.
+	Probes int
+	// OnStateChange, if set, is called after every state change. It must not block.
+	OnStateChange func(from, to CircuitState)
+}
+
+// DefaultCircuitBreakerPolicy is a reasonable CircuitBreakerPolicy for most callers.
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	FailureRate: 0.5,
+	MinCalls:    10,
+	Window:      30 * time.Second,
+	OpenTimeout: 30 * time.Second,
+	Probes:      1,
+}
+
+// WithCircuitBreaker - Option to fail calls fast with a CircuitOpenError while RevenueCat is failing
+//
+// The breaker wraps each call, including its retries, so a call counts once however many attempts it made.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(policy)
+	}
+}
+
+// CircuitState returns the state of the client's circuit breaker, or CircuitClosed if it has none.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+// CircuitOpenError is returned without making a request while the circuit breaker is open.
+type CircuitOpenError struct {
+	// Until is when the circuit will next let a probe call through.
+	Until time.Time
+}
+
+func (err CircuitOpenError) Error() string {
+	return "circuit breaker is open until " + err.Until.Format(time.RFC3339)
+}
+
+// IsCircuitOpen reports whether err is a CircuitOpenError.
+func IsCircuitOpen(err error) bool {
+	var e CircuitOpenError
+	return errors.As(err, &e)
+}
+
+type circuitBreaker struct {
+	policy CircuitBreakerPolicy
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  int
+	windowStart time.Time
+	calls       int
+	failures    int
+	openUntil   time.Time
+	probes      int
+	successes   int
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy) *circuitBreaker {
+	if policy.Probes <= 0 {
+		policy.Probes = 1
+	}
+	if policy.MinCalls <= 0 {
+		policy.MinCalls = 1
+	}
+	return &circuitBreaker{policy: policy, now: time.Now}
+}
+
+// allow reports whether a call made with ctx may be made. If it may, the returned func must be called
+// with the call's error once it finishes.
+func (b *circuitBreaker) allow(ctx context.Context) (func(err error), error) {
+	b.mu.Lock()
+	now := b.now()
+	var changed func()
+	if b.state == CircuitOpen && !now.Before(b.openUntil) {
+		changed = b.setState(CircuitHalfOpen, now)
+	}
+
+	switch {
+	case b.state == CircuitOpen, b.state == CircuitHalfOpen && b.probes >= b.policy.Probes:
+		until := b.openUntil
+		b.mu.Unlock()
+		b.notify(changed)
+		return nil, CircuitOpenError{Until: until}
+	case b.state == CircuitHalfOpen:
+		b.probes++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	b.notify(changed)
+
+	return func(err error) {
+		b.record(ctx, generation, err)
+	}, nil
+}
+
+func (b *circuitBreaker) record(ctx context.Context, generation int, err error) {
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		// The caller gave up or ran out of time, possibly before sending anything, which says nothing
+		// about RevenueCat. Free the probe slot if it was one.
+		b.mu.Lock()
+		if b.generation == generation && b.state == CircuitHalfOpen {
+			b.probes--
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	failed := isBreakerFailure(err)
+	b.mu.Lock()
+	if b.generation != generation {
+		// The call started before the last state change, so it doesn't describe the current state.
+		b.mu.Unlock()
+		return
+	}
+	now := b.now()
+	var changed func()
+	switch b.state {
+	case CircuitHalfOpen:
+		if failed {
+			changed = b.setState(CircuitOpen, now)
+		} else if b.successes++; b.successes >= b.policy.Probes {
+			changed = b.setState(CircuitClosed, now)
+		}
+	case CircuitClosed:
+		if b.policy.Window > 0 && now.Sub(b.windowStart) >= b.policy.Window {
+			b.windowStart, b.calls, b.failures = now, 0, 0
+		}
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if b.calls >= b.policy.MinCalls && float64(b.failures) >= b.policy.FailureRate*float64(b.calls) && b.failures > 0 {
+			changed = b.setState(CircuitOpen, now)
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+}
+
+// setState moves to state and resets its counters. It returns the OnStateChange call to make once
+// the lock is released. b.mu must be held.
+func (b *circuitBreaker) setState(state CircuitState, now time.Time) func() {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.calls, b.failures, b.probes, b.successes = 0, 0, 0, 0
+	b.windowStart = now
+	if state == CircuitOpen {
+		b.openUntil = now.Add(b.policy.OpenTimeout)
+	}
+	if b.policy.OnStateChange == nil {
+		return nil
+	}
+	return func() {
+		b.policy.OnStateChange(from, state)
+	}
+}
+
+func (b *circuitBreaker) notify(changed func()) {
+	if changed != nil {
+		changed()
+	}
+}
+
+// isBreakerFailure reports whether err suggests RevenueCat is unavailable.
+func isBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if e, ok := asError(err); ok {
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package revenuecat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stateChange struct {
+	from, to CircuitState
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	var changes []stateChange
+	policy := DefaultCircuitBreakerPolicy
+	policy.MinCalls = 4
+	policy.OnStateChange = func(from, to CircuitState) {
+		changes = append(changes, stateChange{from, to})
+	}
+	rc := New("apikey", WithCircuitBreaker(policy))
+	rc.http = newMockClient(t, 200, nil, nil)
+
+	rc.GetSubscriber("123")
+	rc.http = newMockClient(t, 502, nil, nil)
+	rc.GetSubscriber("123")
+	rc.GetSubscriber("123")
+	if rc.CircuitState() != CircuitClosed {
+		t.Fatal("expected circuit to stay closed below MinCalls")
+	}
+	rc.GetSubscriber("123")
+	if rc.CircuitState() != CircuitOpen {
+		t.Fatalf("expected circuit to open, got %v", rc.CircuitState())
+	}
+	if len(changes) != 1 || changes[0] != (stateChange{CircuitClosed, CircuitOpen}) {
+		t.Errorf("unexpected state changes: %v", changes)
+	}
+
+	_, err := rc.GetSubscriber("123")
+	var openErr CircuitOpenError
+	if !errors.As(err, &openErr) || !IsCircuitOpen(err) {
+		t.Errorf("expected CircuitOpenError, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	policy := DefaultCircuitBreakerPolicy
+	policy.MinCalls = 2
+	rc := New("apikey", WithCircuitBreaker(policy))
+	rc.http = newMockClient(t, 404, nil, nil)
+
+	for i := 0; i < 5; i++ {
+		rc.GetSubscriber("123")
+	}
+	if rc.CircuitState() != CircuitClosed {
+		t.Errorf("expected 4xx responses not to open the circuit, got %v", rc.CircuitState())
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	var changes []stateChange
+	policy := DefaultCircuitBreakerPolicy
+	policy.MinCalls = 1
+	policy.OnStateChange = func(from, to CircuitState) {
+		changes = append(changes, stateChange{from, to})
+	}
+	clock := fixedClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	rc := New("apikey", WithCircuitBreaker(policy), WithClock(&clock))
+	rc.http = newMockClient(t, 503, nil, nil)
+
+	rc.GetSubscriber("123")
+	if rc.CircuitState() != CircuitOpen {
+		t.Fatalf("expected circuit to open, got %v", rc.CircuitState())
+	}
+
+	// A failed probe opens the circuit again.
+	clock = fixedClock(time.Time(clock).Add(policy.OpenTimeout))
+	if _, err := rc.GetSubscriber("123"); IsCircuitOpen(err) {
+		t.Fatalf("expected a probe to be let through, got %v", err)
+	}
+	if rc.CircuitState() != CircuitOpen {
+		t.Fatalf("expected failed probe to reopen the circuit, got %v", rc.CircuitState())
+	}
+
+	// A successful probe closes it.
+	clock = fixedClock(time.Time(clock).Add(policy.OpenTimeout))
+	rc.http = newMockClient(t, 200, nil, nil)
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if rc.CircuitState() != CircuitClosed {
+		t.Errorf("expected successful probe to close the circuit, got %v", rc.CircuitState())
+	}
+
+	expected := []stateChange{
+		{CircuitClosed, CircuitOpen},
+		{CircuitOpen, CircuitHalfOpen},
+		{CircuitHalfOpen, CircuitOpen},
+		{CircuitOpen, CircuitHalfOpen},
+		{CircuitHalfOpen, CircuitClosed},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, changes)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerLimitsProbes(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerPolicy{MinCalls: 1, OpenTimeout: time.Second, Probes: 1})
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	done, _ := b.allow(context.Background())
+	done(errors.New("connection refused"))
+	now = now.Add(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	probe, err := b.allow(ctx)
+	if err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if _, err := b.allow(context.Background()); !IsCircuitOpen(err) {
+		t.Errorf("expected second call to fail fast while probing, got %v", err)
+	}
+
+	// A cancelled probe frees its slot without closing or opening the circuit.
+	cancel()
+	probe(context.Canceled)
+	if _, err := b.allow(context.Background()); err != nil {
+		t.Errorf("expected a new probe after cancellation, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerDeadline(t *testing.T) {
+	policy := DefaultCircuitBreakerPolicy
+	policy.MinCalls = 2
+	rc := New("apikey", WithCircuitBreaker(policy), WithRateLimits(RateLimits{SubscriberReads: RateLimit{Rate: 0.1, Burst: 1}}))
+	cl := newMockClient(t, 200, nil, nil)
+	rc.http = cl
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := rc.GetSubscriberContext(ctx, "123")
+		cancel()
+		if i == 0 && err != nil {
+			t.Fatalf("error: %v", err)
+		}
+		if i > 0 && !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("call %d: expected to run out of time waiting for a token, got %v", i+1, err)
+		}
+	}
+	if rc.CircuitState() != CircuitClosed {
+		t.Errorf("expected the caller's deadline not to open the circuit, got %v", rc.CircuitState())
+	}
+}
@@ -0,0 +1,52 @@
+package bulk
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// checkpoint is an append-only file of the rows that have succeeded, one "line<TAB>op<TAB>user_id" per line,
+// where line is Row.Line. Keying on the op and user as well as the line stops an edited input file from
+// skipping the wrong rows.
+type checkpoint struct {
+	f    *os.File
+	seen map[string]bool
+}
+
+func openCheckpoint(path string) (*checkpoint, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint: %v", err)
+	}
+	cp := &checkpoint{f: f, seen: make(map[string]bool)}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		cp.seen[sc.Text()] = true
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+	return cp, nil
+}
+
+func checkpointKey(row Row) string {
+	return strconv.Itoa(row.Line) + "\t" + string(row.Op) + "\t" + row.UserID
+}
+
+func (cp *checkpoint) done(row Row) bool {
+	return cp.seen[checkpointKey(row)]
+}
+
+func (cp *checkpoint) add(row Row) error {
+	if _, err := fmt.Fprintln(cp.f, checkpointKey(row)); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
+
+func (cp *checkpoint) Close() error {
+	return cp.f.Close()
+}
@@ -0,0 +1,300 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// API is the subset of revenuecat.API the Executor uses.
+type API interface {
+	revenuecat.EntitlementManager
+	revenuecat.SubscriberManager
+}
+
+// Status is the outcome of a row.
+type Status string
+
+const (
+	// StatusOK means the operation succeeded.
+	StatusOK Status = "ok"
+	// StatusFailed means the row was invalid or the operation failed.
+	StatusFailed Status = "failed"
+	// StatusSkipped means the row had already succeeded according to the checkpoint.
+	StatusSkipped Status = "skipped"
+)
+
+// Result holds the outcome of a row.
+type Result struct {
+	Line   int    `json:"line"`
+	Op     Op     `json:"op"`
+	UserID string `json:"user_id"`
+	Status Status `json:"status"`
+	Err    error  `json:"-"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Summary counts the results of a run.
+type Summary struct {
+	OK      int
+	Failed  int
+	Skipped int
+}
+
+// Executor runs rows against the API with bounded concurrency.
+type Executor struct {
+	client      API
+	concurrency int
+	interval    time.Duration
+	maxRetries  int
+	checkpoint  string
+	results     io.Writer
+	sleep       func(ctx context.Context, d time.Duration) error
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+type Option func(*Executor)
+
+// NewExecutor returns a new *Executor. By default it runs 4 rows at a time.
+func NewExecutor(client API, opts ...Option) *Executor {
+	e := &Executor{
+		client:      client,
+		concurrency: 4,
+		maxRetries:  5,
+		sleep:       sleepContext,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// WithConcurrency - Option to set how many rows run at the same time
+func WithConcurrency(n int) Option {
+	return func(e *Executor) {
+		if n > 0 {
+			e.concurrency = n
+		}
+	}
+}
+
+// WithRate - Option to limit how many rows start per second, shared across all workers
+func WithRate(perSecond float64) Option {
+	return func(e *Executor) {
+		if perSecond > 0 {
+			e.interval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// WithCheckpoint - Option to record succeeded rows in the file at path, and skip rows already recorded
+// there, so an interrupted run can be resumed
+func WithCheckpoint(path string) Option {
+	return func(e *Executor) {
+		e.checkpoint = path
+	}
+}
+
+// WithResults - Option to write each result to w as a JSON line as soon as its row finishes
+func WithResults(w io.Writer) Option {
+	return func(e *Executor) {
+		e.results = w
+	}
+}
+
+// Run runs the rows and returns their results in row order. Rows that are still rate limited after the
+// client's own retries pause every worker for the Retry-After period and are tried again.
+// If ctx is cancelled, rows in progress finish, the remaining rows are left out of the results and
+// ctx's error is returned.
+func (e *Executor) Run(ctx context.Context, rows []Row) ([]Result, Summary, error) {
+	var cp *checkpoint
+	if e.checkpoint != "" {
+		var err error
+		cp, err = openCheckpoint(e.checkpoint)
+		if err != nil {
+			return nil, Summary{}, err
+		}
+		defer cp.Close()
+	}
+
+	results := make([]*Result, len(rows))
+	var resultsMu sync.Mutex
+	var writeErr error
+	finish := func(i int, res Result) {
+		resultsMu.Lock()
+		defer resultsMu.Unlock()
+		results[i] = &res
+		if res.Status == StatusOK && cp != nil {
+			if err := cp.add(rows[i]); err != nil && writeErr == nil {
+				writeErr = err
+			}
+		}
+		if e.results != nil {
+			if err := json.NewEncoder(e.results).Encode(res); err != nil && writeErr == nil {
+				writeErr = fmt.Errorf("error writing result: %v", err)
+			}
+		}
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < e.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				res, ok := e.runRow(ctx, rows[i])
+				if ok {
+					finish(i, res)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i, row := range rows {
+		if cp != nil && cp.done(row) {
+			finish(i, newResult(row, StatusSkipped, nil))
+			continue
+		}
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	var out []Result
+	var summary Summary
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		switch res.Status {
+		case StatusOK:
+			summary.OK++
+		case StatusFailed:
+			summary.Failed++
+		case StatusSkipped:
+			summary.Skipped++
+		}
+		out = append(out, *res)
+	}
+	if err := ctx.Err(); err != nil {
+		return out, summary, err
+	}
+	return out, summary, writeErr
+}
+
+// runRow runs a single row, retrying it while it is rate limited. It returns false if ctx was
+// cancelled before the row finished.
+func (e *Executor) runRow(ctx context.Context, row Row) (Result, bool) {
+	if err := row.Validate(); err != nil {
+		return newResult(row, StatusFailed, err), true
+	}
+	for attempt := 0; ; attempt++ {
+		if err := e.wait(ctx); err != nil {
+			return Result{}, false
+		}
+		err := e.do(ctx, row)
+		if err != nil && ctx.Err() != nil {
+			return Result{}, false
+		}
+		if err == nil {
+			return newResult(row, StatusOK, nil), true
+		}
+		if !revenuecat.IsRateLimited(err) || attempt >= e.maxRetries {
+			return newResult(row, StatusFailed, err), true
+		}
+		e.pause(retryAfter(err))
+	}
+}
+
+func (e *Executor) do(ctx context.Context, row Row) error {
+	var err error
+	switch row.Op {
+	case OpGrant:
+		_, err = e.client.GrantEntitlementContext(ctx, row.UserID, row.Entitlement, row.Duration, row.StartTime)
+	case OpRevoke:
+		_, err = e.client.RevokeEntitlementContext(ctx, row.UserID, row.Entitlement)
+	case OpAttributes:
+		err = e.client.UpdateSubscriberAttributesContext(ctx, row.UserID, row.Attributes)
+	case OpDelete:
+		err = e.client.DeleteSubscriberContext(ctx, row.UserID)
+	}
+	return err
+}
+
+// wait blocks until the worker may start its next request, according to the rate and any pause.
+func (e *Executor) wait(ctx context.Context) error {
+	e.mu.Lock()
+	now := time.Now()
+	start := e.next
+	if start.Before(now) {
+		start = now
+	}
+	e.next = start.Add(e.interval)
+	e.mu.Unlock()
+	return e.sleep(ctx, start.Sub(now))
+}
+
+// pause stops every worker from starting a request for d.
+func (e *Executor) pause(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if until := time.Now().Add(d); until.After(e.next) {
+		e.next = until
+	}
+}
+
+// defaultRetryAfter is how long to pause when a rate limited response has no Retry-After header.
+const defaultRetryAfter = time.Second
+
+func retryAfter(err error) time.Duration {
+	var rcErr revenuecat.Error
+	if errors.As(err, &rcErr) && rcErr.Response != nil {
+		if secs, perr := strconv.Atoi(rcErr.Response.Header.Get("Retry-After")); perr == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultRetryAfter
+}
+
+func newResult(row Row, status Status, err error) Result {
+	res := Result{
+		Line:   row.Line,
+		Op:     row.Op,
+		UserID: row.UserID,
+		Status: status,
+		Err:    err,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
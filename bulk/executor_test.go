@@ -0,0 +1,248 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/revenuecattest"
+)
+
+func newTestExecutor(client API, opts ...Option) *Executor {
+	e := NewExecutor(client, opts...)
+	e.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return e
+}
+
+func TestRun(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	srv.Client().GetSubscriber("789")
+
+	rows := []Row{
+		{Line: 2, Op: OpGrant, UserID: "123", Entitlement: "premium", Duration: revenuecat.Monthly},
+		{Line: 3, Op: OpAttributes, UserID: "456", Attributes: map[string]revenuecat.SubscriberAttribute{"plan": {Value: "gold"}}},
+		{Line: 4, Op: OpDelete, UserID: "789"},
+		{Line: 5, Op: OpRevoke, UserID: "123"},
+	}
+	var out bytes.Buffer
+	results, summary, err := newTestExecutor(srv.Client(), WithResults(&out)).Run(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if summary != (Summary{OK: 3, Failed: 1}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	for i, res := range results {
+		if res.Line != rows[i].Line {
+			t.Errorf("expected results in row order, got %+v", results)
+		}
+	}
+	if results[3].Status != StatusFailed || results[3].Err == nil {
+		t.Errorf("expected invalid row to fail, got %+v", results[3])
+	}
+
+	if sub, _ := srv.Subscriber("123"); !sub.IsEntitledTo("premium") {
+		t.Error("expected premium to be granted")
+	}
+	if sub, _ := srv.Subscriber("456"); sub.SubscriberAttributes["plan"].Value != "gold" {
+		t.Errorf("unexpected attributes: %+v", sub.SubscriberAttributes)
+	}
+	if _, ok := srv.Subscriber("789"); ok {
+		t.Error("expected subscriber to be deleted")
+	}
+
+	dec := json.NewDecoder(&out)
+	lines := 0
+	for dec.More() {
+		var res Result
+		if err := dec.Decode(&res); err != nil {
+			t.Fatalf("error decoding result: %v", err)
+		}
+		if res.Status == StatusFailed && res.Error == "" {
+			t.Errorf("expected error message for failed row, got %+v", res)
+		}
+		lines++
+	}
+	if lines != len(rows) {
+		t.Errorf("expected %d result lines, got %d", len(rows), lines)
+	}
+}
+
+func TestRunConcurrency(t *testing.T) {
+	var running, max int32
+	stub := &revenuecattest.Stub{
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		},
+	}
+	var rows []Row
+	for i := 0; i < 20; i++ {
+		rows = append(rows, Row{Line: i + 1, Op: OpDelete, UserID: "user"})
+	}
+
+	_, summary, err := newTestExecutor(stub, WithConcurrency(3)).Run(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if summary.OK != 20 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if max > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", max)
+	}
+}
+
+func TestRunRateLimited(t *testing.T) {
+	var calls int32
+	stub := &revenuecattest.Stub{
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return revenuecat.Error{
+					StatusCode: http.StatusTooManyRequests,
+					Response:   &revenuecat.ResponseDetails{Header: http.Header{"Retry-After": []string{"2"}}},
+				}
+			}
+			return nil
+		},
+	}
+	e := NewExecutor(stub, WithConcurrency(1))
+	var mu sync.Mutex
+	var slept time.Duration
+	e.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		slept += d
+		mu.Unlock()
+		return nil
+	}
+
+	results, _, err := e.Run(context.Background(), []Row{{Line: 1, Op: OpDelete, UserID: "123"}})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if results[0].Status != StatusOK || calls != 2 {
+		t.Errorf("expected row to succeed on retry, got %+v after %d calls", results[0], calls)
+	}
+	if slept < time.Second {
+		t.Errorf("expected to pause for Retry-After, slept %v", slept)
+	}
+}
+
+func TestRunCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	fail := true
+	stub := &revenuecattest.Stub{
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			if userID == "456" && fail {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}
+	rows := []Row{
+		{Line: 1, Op: OpDelete, UserID: "123"},
+		{Line: 2, Op: OpDelete, UserID: "456"},
+	}
+
+	_, summary, err := newTestExecutor(stub, WithCheckpoint(path)).Run(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if summary != (Summary{OK: 1, Failed: 1}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	fail = false
+	stub.Reset()
+	results, summary, err := newTestExecutor(stub, WithCheckpoint(path)).Run(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if summary != (Summary{OK: 1, Skipped: 1}) || results[0].Status != StatusSkipped {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if calls := stub.CallsTo("DeleteSubscriber"); len(calls) != 1 || calls[0].Args[0] != "456" {
+		t.Errorf("expected only the failed row to be retried, got %+v", calls)
+	}
+}
+
+func TestRunCheckpointMultilineCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	in := "op,user_id,entitlement,duration,attr:note\n" +
+		"attributes,123,,,\"first\nsecond\"\n" +
+		"grant,456,premium,monthly,\n" +
+		"delete,789,,,\n"
+	fail := true
+	stub := &revenuecattest.Stub{
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			if fail {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}
+	run := func() Summary {
+		t.Helper()
+		rows, err := ReadCSV(strings.NewReader(in))
+		if err != nil {
+			t.Fatalf("error: %v", err)
+		}
+		_, summary, err := newTestExecutor(stub, WithCheckpoint(path)).Run(context.Background(), rows)
+		if err != nil {
+			t.Fatalf("error: %v", err)
+		}
+		return summary
+	}
+
+	if summary := run(); summary != (Summary{OK: 2, Failed: 1}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	fail = false
+	stub.Reset()
+	if summary := run(); summary != (Summary{OK: 1, Skipped: 2}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if calls := stub.Calls(); len(calls) != 1 || calls[0].Method != "DeleteSubscriber" {
+		t.Errorf("expected only the failed row to be retried, got %+v", calls)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stub := &revenuecattest.Stub{
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			cancel()
+			return nil
+		},
+	}
+	var rows []Row
+	for i := 0; i < 10; i++ {
+		rows = append(rows, Row{Line: i + 1, Op: OpDelete, UserID: "user"})
+	}
+
+	results, _, err := newTestExecutor(stub, WithConcurrency(1)).Run(ctx, rows)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(results) >= len(rows) {
+		t.Errorf("expected remaining rows to be left out, got %d results", len(results))
+	}
+}
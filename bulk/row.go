@@ -0,0 +1,177 @@
+// Package bulk runs RevenueCat operations for many subscribers, read from CSV or JSON lines.
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// Op is the operation to run for a row.
+type Op string
+
+const (
+	// OpGrant grants a promotional entitlement with GrantEntitlement.
+	OpGrant Op = "grant"
+	// OpRevoke revokes promotional entitlements with RevokeEntitlement.
+	OpRevoke Op = "revoke"
+	// OpAttributes updates subscriber attributes with UpdateSubscriberAttributes.
+	OpAttributes Op = "attributes"
+	// OpDelete permanently deletes a subscriber with DeleteSubscriber.
+	OpDelete Op = "delete"
+)
+
+// Row is a single operation for one subscriber.
+type Row struct {
+	// Line identifies the row in results and checkpoints, starting at 1. For JSON lines it is the line the row
+	// was read from. For CSV it is the record number, counting the header as 1, so a quoted field spanning
+	// several lines doesn't shift the rows after it.
+	Line        int
+	Op          Op
+	UserID      string
+	Entitlement string
+	Duration    revenuecat.Duration
+	StartTime   time.Time
+	Attributes  map[string]revenuecat.SubscriberAttribute
+}
+
+// Validate checks that the row has the fields its Op needs.
+func (r Row) Validate() error {
+	if r.UserID == "" {
+		return errors.New("missing user_id")
+	}
+	switch r.Op {
+	case OpGrant:
+		if r.Entitlement == "" || r.Duration == "" {
+			return errors.New("grant needs entitlement and duration")
+		}
+	case OpRevoke:
+		if r.Entitlement == "" {
+			return errors.New("revoke needs entitlement")
+		}
+	case OpAttributes:
+		if len(r.Attributes) == 0 {
+			return errors.New("attributes needs at least one attribute")
+		}
+	case OpDelete:
+	case "":
+		return errors.New("missing op")
+	default:
+		return fmt.Errorf("unknown op %q", r.Op)
+	}
+	return nil
+}
+
+// ReadCSV reads rows from CSV with a header record. The recognised columns are op, user_id, entitlement,
+// duration and start_time (RFC 3339), and each column named attr:<key> sets the attribute key.
+// Empty attribute cells are ignored. Rows are not validated, so an op can be filled in later.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %v", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	if _, ok := cols["user_id"]; !ok {
+		return nil, errors.New("missing user_id column")
+	}
+
+	var rows []Row
+	// The header is record 1. Rows are numbered by record rather than by physical line, so they stay stable
+	// when quoted fields contain newlines.
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := Row{
+			Line:        line,
+			Op:          Op(get("op")),
+			UserID:      get("user_id"),
+			Entitlement: get("entitlement"),
+			Duration:    revenuecat.Duration(get("duration")),
+		}
+		if s := get("start_time"); s != "" {
+			row.StartTime, err = time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: invalid start_time %q", line, s)
+			}
+		}
+		for name, i := range cols {
+			if !strings.HasPrefix(name, "attr:") || i >= len(record) || record[i] == "" {
+				continue
+			}
+			if row.Attributes == nil {
+				row.Attributes = make(map[string]revenuecat.SubscriberAttribute)
+			}
+			row.Attributes[strings.TrimPrefix(name, "attr:")] = revenuecat.SubscriberAttribute{Value: record[i]}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ReadJSONL reads rows from JSON lines, one object per line with the same fields as the CSV columns.
+// Attributes are an object of key to value. Blank lines are skipped.
+//
+//	{"op": "attributes", "user_id": "123", "attributes": {"$email": "jane@example.com"}}
+func ReadJSONL(r io.Reader) ([]Row, error) {
+	var rows []Row
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var jsonRow struct {
+			Op          Op                `json:"op"`
+			UserID      string            `json:"user_id"`
+			Entitlement string            `json:"entitlement"`
+			Duration    string            `json:"duration"`
+			StartTime   time.Time         `json:"start_time"`
+			Attributes  map[string]string `json:"attributes"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &jsonRow); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		row := Row{
+			Line:        line,
+			Op:          jsonRow.Op,
+			UserID:      jsonRow.UserID,
+			Entitlement: jsonRow.Entitlement,
+			Duration:    revenuecat.Duration(jsonRow.Duration),
+			StartTime:   jsonRow.StartTime,
+		}
+		if len(jsonRow.Attributes) > 0 {
+			row.Attributes = make(map[string]revenuecat.SubscriberAttribute, len(jsonRow.Attributes))
+			for k, v := range jsonRow.Attributes {
+				row.Attributes[k] = revenuecat.SubscriberAttribute{Value: v}
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,104 @@
+package bulk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestReadCSV(t *testing.T) {
+	in := `op,user_id,entitlement,duration,start_time,attr:$email,attr:plan
+grant,123,premium,monthly,2020-01-16T00:00:00Z,,
+attributes,456,,,,jane@example.com,gold
+delete,789,,,,,
+`
+	rows, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	want := []Row{
+		{Line: 2, Op: OpGrant, UserID: "123", Entitlement: "premium", Duration: revenuecat.Monthly, StartTime: time.Date(2020, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{Line: 3, Op: OpAttributes, UserID: "456", Attributes: map[string]revenuecat.SubscriberAttribute{
+			"$email": {Value: "jane@example.com"},
+			"plan":   {Value: "gold"},
+		}},
+		{Line: 4, Op: OpDelete, UserID: "789"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %+v, got %+v", want, rows)
+	}
+}
+
+func TestReadCSVMultilineField(t *testing.T) {
+	in := "op,user_id,attr:note\n" +
+		"attributes,123,\"first\nsecond\"\n" +
+		"delete,456,\n" +
+		"attributes,789,\"a\r\nb\nc\"\n" +
+		"delete,999,\n"
+	rows, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	var lines []int
+	for _, row := range rows {
+		lines = append(lines, row.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 3, 4, 5}) {
+		t.Errorf("expected rows to be numbered by record, got %v", lines)
+	}
+	if note := rows[0].Attributes["note"].Value; note != "first\nsecond" {
+		t.Errorf("unexpected multi-line value: %q", note)
+	}
+}
+
+func TestReadCSVMissingUserID(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("op,id\ndelete,123\n")); err == nil {
+		t.Error("expected error for missing user_id column")
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	in := `{"op":"revoke","user_id":"123","entitlement":"premium"}
+
+{"op":"attributes","user_id":"456","attributes":{"plan":"gold"}}
+`
+	rows, err := ReadJSONL(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	want := []Row{
+		{Line: 1, Op: OpRevoke, UserID: "123", Entitlement: "premium"},
+		{Line: 3, Op: OpAttributes, UserID: "456", Attributes: map[string]revenuecat.SubscriberAttribute{"plan": {Value: "gold"}}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %+v, got %+v", want, rows)
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("{\"op\":\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected error with line number, got %v", err)
+	}
+}
+
+func TestRowValidate(t *testing.T) {
+	tests := []struct {
+		row   Row
+		valid bool
+	}{
+		{Row{Op: OpGrant, UserID: "123", Entitlement: "premium", Duration: revenuecat.Monthly}, true},
+		{Row{Op: OpGrant, UserID: "123", Entitlement: "premium"}, false},
+		{Row{Op: OpRevoke, UserID: "123"}, false},
+		{Row{Op: OpAttributes, UserID: "123"}, false},
+		{Row{Op: OpDelete, UserID: "123"}, true},
+		{Row{Op: OpDelete}, false},
+		{Row{UserID: "123"}, false},
+		{Row{Op: "frobnicate", UserID: "123"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.row.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: expected valid=%v, got %v", tt.row, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package revenuecat
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedClient wraps a *Client and caches GetSubscriber results per user ID.
+// Mutating calls refresh the cached entry with the Subscriber they return, or evict it when they don't return one.
+type CachedClient struct {
+	*Client
+
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	fills   map[string]*cacheFill
+}
+
+type cacheEntry struct {
+	userID    string
+	sub       Subscriber
+	expiresAt time.Time
+}
+
+// cacheFill tracks the GetSubscriber requests in flight for a user. gen is bumped by mutations and Invalidate,
+// so a request that started before them doesn't overwrite their result with a stale subscriber.
+type cacheFill struct {
+	refs int
+	gen  uint64
+}
+
+// NewCachedClient returns a new *CachedClient that keeps up to size subscribers for ttl each,
+// evicting the least recently used subscriber when full.
+func NewCachedClient(c *Client, ttl time.Duration, size int) *CachedClient {
+	return &CachedClient{
+		Client:  c,
+		ttl:     ttl,
+		size:    size,
+		now:     c.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		fills:   make(map[string]*cacheFill),
+	}
+}
+
+// Invalidate evicts the cached subscriber for userID. GetSubscriber requests already in flight for userID
+// won't cache their result.
+func (c *CachedClient) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bump(userID)
+	if el, ok := c.entries[userID]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, userID)
+	}
+}
+
+func (c *CachedClient) get(userID string) (Subscriber, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[userID]
+	if !ok {
+		return Subscriber{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.entries, userID)
+		return Subscriber{}, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.sub.Clone(), true
+}
+
+func (c *CachedClient) set(userID string, sub Subscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(userID, sub)
+}
+
+// setLocked stores sub for userID. c.mu must be held.
+func (c *CachedClient) setLocked(userID string, sub Subscriber) {
+	if c.size <= 0 {
+		return
+	}
+	entry := &cacheEntry{userID: userID, sub: sub.Clone(), expiresAt: c.now().Add(c.ttl)}
+	if el, ok := c.entries[userID]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[userID] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).userID)
+	}
+}
+
+// bump marks the GetSubscriber requests in flight for userID as stale. c.mu must be held.
+func (c *CachedClient) bump(userID string) {
+	if f, ok := c.fills[userID]; ok {
+		f.gen++
+	}
+}
+
+// startFill registers a GetSubscriber request for userID and returns the generation to pass to finishFill.
+func (c *CachedClient) startFill(userID string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.fills[userID]
+	if !ok {
+		f = &cacheFill{}
+		c.fills[userID] = f
+	}
+	f.refs++
+	return f.gen
+}
+
+// finishFill caches sub for userID, unless a mutation or Invalidate happened since startFill returned gen.
+func (c *CachedClient) finishFill(userID string, gen uint64, sub Subscriber, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.fills[userID]
+	f.refs--
+	if f.refs == 0 {
+		delete(c.fills, userID)
+	}
+	if ok && f.gen == gen {
+		c.setLocked(userID, sub)
+	}
+}
+
+// update returns a function that stores the result of a successful mutation for userID,
+// or evicts the entry if the mutation failed.
+func (c *CachedClient) update(userID string) func(Subscriber, error) (Subscriber, error) {
+	return func(sub Subscriber, err error) (Subscriber, error) {
+		if err != nil {
+			c.Invalidate(userID)
+			return sub, err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.bump(userID)
+		c.setLocked(userID, sub)
+		return sub, nil
+	}
+}
+
+// GetSubscriber returns the cached subscriber, or gets and caches the latest subscriber info.
+func (c *CachedClient) GetSubscriber(userID string) (Subscriber, error) {
+	return c.GetSubscriberWithPlatformContext(context.Background(), userID, "")
+}
+
+// GetSubscriberContext is like GetSubscriber but uses ctx for the request.
+func (c *CachedClient) GetSubscriberContext(ctx context.Context, userID string) (Subscriber, error) {
+	return c.GetSubscriberWithPlatformContext(ctx, userID, "")
+}
+
+// GetSubscriberWithPlatform returns the cached subscriber, or gets and caches the latest subscriber info.
+// A cache hit makes no request, so the subscriber's last_seen for the platform isn't updated.
+func (c *CachedClient) GetSubscriberWithPlatform(userID string, platform string) (Subscriber, error) {
+	return c.GetSubscriberWithPlatformContext(context.Background(), userID, platform)
+}
+
+// GetSubscriberWithPlatformContext is like GetSubscriberWithPlatform but uses ctx for the request.
+func (c *CachedClient) GetSubscriberWithPlatformContext(ctx context.Context, userID string, platform string) (Subscriber, error) {
+	if sub, ok := c.get(userID); ok {
+		return sub, nil
+	}
+	gen := c.startFill(userID)
+	sub, err := c.Client.GetSubscriberWithPlatformContext(ctx, userID, platform)
+	c.finishFill(userID, gen, sub, err == nil)
+	return sub, err
+}
+
+// CreatePurchase records a purchase and caches the returned subscriber.
+func (c *CachedClient) CreatePurchase(userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error) {
+	return c.CreatePurchaseContext(context.Background(), userID, receipt, opt)
+}
+
+// CreatePurchaseContext is like CreatePurchase but uses ctx for the request.
+func (c *CachedClient) CreatePurchaseContext(ctx context.Context, userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error) {
+	return c.update(userID)(c.Client.CreatePurchaseContext(ctx, userID, receipt, opt))
+}
+
+// GrantEntitlement grants a promotional entitlement and caches the returned subscriber.
+func (c *CachedClient) GrantEntitlement(userID string, id string, duration Duration, startTime time.Time) (Subscriber, error) {
+	return c.GrantEntitlementContext(context.Background(), userID, id, duration, startTime)
+}
+
+// GrantEntitlementContext is like GrantEntitlement but uses ctx for the request.
+func (c *CachedClient) GrantEntitlementContext(ctx context.Context, userID string, id string, duration Duration, startTime time.Time) (Subscriber, error) {
+	return c.update(userID)(c.Client.GrantEntitlementContext(ctx, userID, id, duration, startTime))
+}
+
+// RevokeEntitlement revokes promotional entitlements and caches the returned subscriber.
+func (c *CachedClient) RevokeEntitlement(userID string, id string) (Subscriber, error) {
+	return c.RevokeEntitlementContext(context.Background(), userID, id)
+}
+
+// RevokeEntitlementContext is like RevokeEntitlement but uses ctx for the request.
+func (c *CachedClient) RevokeEntitlementContext(ctx context.Context, userID string, id string) (Subscriber, error) {
+	return c.update(userID)(c.Client.RevokeEntitlementContext(ctx, userID, id))
+}
+
+// OverrideOffering overrides the current Offering and caches the returned subscriber.
+func (c *CachedClient) OverrideOffering(userID string, offeringUUID string) (Subscriber, error) {
+	return c.OverrideOfferingContext(context.Background(), userID, offeringUUID)
+}
+
+// OverrideOfferingContext is like OverrideOffering but uses ctx for the request.
+func (c *CachedClient) OverrideOfferingContext(ctx context.Context, userID string, offeringUUID string) (Subscriber, error) {
+	return c.update(userID)(c.Client.OverrideOfferingContext(ctx, userID, offeringUUID))
+}
+
+// DeleteOfferingOverride resets the offering override and caches the returned subscriber.
+func (c *CachedClient) DeleteOfferingOverride(userID string) (Subscriber, error) {
+	return c.DeleteOfferingOverrideContext(context.Background(), userID)
+}
+
+// DeleteOfferingOverrideContext is like DeleteOfferingOverride but uses ctx for the request.
+func (c *CachedClient) DeleteOfferingOverrideContext(ctx context.Context, userID string) (Subscriber, error) {
+	return c.update(userID)(c.Client.DeleteOfferingOverrideContext(ctx, userID))
+}
+
+// RefundGoogleSubscription refunds a Google Subscription and caches the returned subscriber.
+func (c *CachedClient) RefundGoogleSubscription(userID string, id string) (Subscriber, error) {
+	return c.RefundGoogleSubscriptionContext(context.Background(), userID, id)
+}
+
+// RefundGoogleSubscriptionContext is like RefundGoogleSubscription but uses ctx for the request.
+func (c *CachedClient) RefundGoogleSubscriptionContext(ctx context.Context, userID string, id string) (Subscriber, error) {
+	return c.update(userID)(c.Client.RefundGoogleSubscriptionContext(ctx, userID, id))
+}
+
+// DeferGoogleSubscription defers a Google Subscription and caches the returned subscriber.
+func (c *CachedClient) DeferGoogleSubscription(userID string, id string, nextExpiry time.Time) (Subscriber, error) {
+	return c.DeferGoogleSubscriptionContext(context.Background(), userID, id, nextExpiry)
+}
+
+// DeferGoogleSubscriptionContext is like DeferGoogleSubscription but uses ctx for the request.
+func (c *CachedClient) DeferGoogleSubscriptionContext(ctx context.Context, userID string, id string, nextExpiry time.Time) (Subscriber, error) {
+	return c.update(userID)(c.Client.DeferGoogleSubscriptionContext(ctx, userID, id, nextExpiry))
+}
+
+// UpdateSubscriberAttributes updates subscriber attributes and evicts the cached subscriber.
+func (c *CachedClient) UpdateSubscriberAttributes(userID string, attributes map[string]SubscriberAttribute) error {
+	return c.UpdateSubscriberAttributesContext(context.Background(), userID, attributes)
+}
+
+// UpdateSubscriberAttributesContext is like UpdateSubscriberAttributes but uses ctx for the request.
+func (c *CachedClient) UpdateSubscriberAttributesContext(ctx context.Context, userID string, attributes map[string]SubscriberAttribute) error {
+	defer c.Invalidate(userID)
+	return c.Client.UpdateSubscriberAttributesContext(ctx, userID, attributes)
+}
+
+// AddUserAttribution attaches attribution data and evicts the cached subscriber.
+func (c *CachedClient) AddUserAttribution(userID string, network Network, data AttributionData) error {
+	return c.AddUserAttributionContext(context.Background(), userID, network, data)
+}
+
+// AddUserAttributionContext is like AddUserAttribution but uses ctx for the request.
+func (c *CachedClient) AddUserAttributionContext(ctx context.Context, userID string, network Network, data AttributionData) error {
+	defer c.Invalidate(userID)
+	return c.Client.AddUserAttributionContext(ctx, userID, network, data)
+}
+
+// DeleteSubscriber permanently deletes a subscriber and evicts the cached subscriber.
+func (c *CachedClient) DeleteSubscriber(userID string) error {
+	return c.DeleteSubscriberContext(context.Background(), userID)
+}
+
+// DeleteSubscriberContext is like DeleteSubscriber but uses ctx for the request.
+func (c *CachedClient) DeleteSubscriberContext(ctx context.Context, userID string) error {
+	defer c.Invalidate(userID)
+	return c.Client.DeleteSubscriberContext(ctx, userID)
+}
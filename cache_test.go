@@ -0,0 +1,206 @@
+package revenuecat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCountingClient returns a mockClient that replies with a subscriber whose original_app_user_id is the
+// request path, and counts the requests it receives.
+func newCountingClient(t *testing.T) (*mockClient, *int) {
+	t.Helper()
+	var count int
+	c := &mockClient{}
+	c.doer = func(req *http.Request) (*http.Response, error) {
+		count++
+		body := fmt.Sprintf(`{"subscriber":{"original_app_user_id":%q}}`, fmt.Sprintf("%s %d", req.URL.Path, count))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+	return c, &count
+}
+
+func TestCachedClientGetSubscriber(t *testing.T) {
+	cl, count := newCountingClient(t)
+	rc := New("apikey")
+	rc.http = cl
+	now := time.Now()
+	cc := NewCachedClient(rc, time.Minute, 10)
+	cc.now = func() time.Time { return now }
+
+	first, err := cc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	second, _ := cc.GetSubscriberWithPlatform("123", "ios")
+	if *count != 1 || first.OriginalAppUserID != second.OriginalAppUserID {
+		t.Errorf("expected a single request, got: %d", *count)
+	}
+
+	now = now.Add(time.Minute)
+	cc.GetSubscriber("123")
+	if *count != 2 {
+		t.Errorf("expected expired entry to be refetched, got: %d requests", *count)
+	}
+}
+
+func TestCachedClientLRU(t *testing.T) {
+	cl, count := newCountingClient(t)
+	rc := New("apikey")
+	rc.http = cl
+	cc := NewCachedClient(rc, time.Minute, 2)
+
+	cc.GetSubscriber("1")
+	cc.GetSubscriber("2")
+	cc.GetSubscriber("1")
+	cc.GetSubscriber("3") // Evicts 2, the least recently used.
+	cc.GetSubscriber("1")
+	if *count != 3 {
+		t.Errorf("expected 3 requests, got: %d", *count)
+	}
+	cc.GetSubscriber("2")
+	if *count != 4 {
+		t.Errorf("expected 2 to have been evicted, got: %d requests", *count)
+	}
+}
+
+func TestCachedClientMutations(t *testing.T) {
+	cl, count := newCountingClient(t)
+	rc := New("apikey")
+	rc.http = cl
+	cc := NewCachedClient(rc, time.Minute, 10)
+
+	granted, err := cc.GrantEntitlement("123", "all", Monthly, time.Time{})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	sub, _ := cc.GetSubscriber("123")
+	if *count != 1 || sub.OriginalAppUserID != granted.OriginalAppUserID {
+		t.Errorf("expected the granted subscriber to be cached, got: %q after %d requests", sub.OriginalAppUserID, *count)
+	}
+
+	cc.UpdateSubscriberAttributes("123", map[string]SubscriberAttribute{"foo": {Value: "bar"}})
+	cc.GetSubscriber("123")
+	if *count != 3 {
+		t.Errorf("expected attribute update to evict the subscriber, got: %d requests", *count)
+	}
+
+	cc.DeleteSubscriber("123")
+	cc.GetSubscriber("123")
+	if *count != 5 {
+		t.Errorf("expected delete to evict the subscriber, got: %d requests", *count)
+	}
+}
+
+func TestCachedClientMutationError(t *testing.T) {
+	cl, count := newCountingClient(t)
+	rc := New("apikey")
+	rc.http = cl
+	cc := NewCachedClient(rc, time.Minute, 10)
+
+	cc.GetSubscriber("123")
+	cl.doer = func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection reset")
+	}
+	if _, err := cc.RevokeEntitlement("123", "all"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := cc.get("123"); ok {
+		t.Error("expected failed mutation to evict the subscriber")
+	}
+	if *count != 1 {
+		t.Errorf("expected 1 request, got: %d", *count)
+	}
+}
+
+func TestCachedClientReturnsCopies(t *testing.T) {
+	rc := New("apikey")
+	cc := NewCachedClient(rc, time.Minute, 10)
+	cc.set("123", Subscriber{Entitlements: map[string]Entitlement{"premium": {}}})
+
+	sub, _ := cc.get("123")
+	delete(sub.Entitlements, "premium")
+	if sub, _ := cc.get("123"); len(sub.Entitlements) != 1 {
+		t.Error("expected cached subscriber to be unaffected by caller mutation")
+	}
+}
+
+func TestCachedClientDropsStaleFill(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(cc *CachedClient) error
+		expected string
+	}{{
+		name: "grant",
+		mutate: func(cc *CachedClient) error {
+			_, err := cc.GrantEntitlement("123", "pro", Monthly, time.Time{})
+			return err
+		},
+		expected: "fresh",
+	}, {
+		name: "delete",
+		mutate: func(cc *CachedClient) error {
+			return cc.DeleteSubscriber("123")
+		},
+	}, {
+		name: "invalidate",
+		mutate: func(cc *CachedClient) error {
+			cc.Invalidate("123")
+			return nil
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// GET requests block until released and answer with a stale subscriber, while the mutations
+			// answer immediately with a fresh one.
+			started := make(chan struct{})
+			release := make(chan struct{})
+			cl := &mockClient{}
+			cl.doer = func(req *http.Request) (*http.Response, error) {
+				name := "fresh"
+				if req.Method == http.MethodGet {
+					started <- struct{}{}
+					<-release
+					name = "stale"
+				}
+				body := fmt.Sprintf(`{"subscriber":{"original_app_user_id":%q}}`, name)
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+			}
+			rc := New("apikey")
+			rc.http = cl
+			cc := NewCachedClient(rc, time.Minute, 10)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				cc.GetSubscriber("123")
+			}()
+			<-started
+			if err := test.mutate(cc); err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			close(release)
+			<-done
+
+			sub, ok := cc.get("123")
+			if test.expected == "" {
+				if ok {
+					t.Errorf("expected no cached subscriber, got: %q", sub.OriginalAppUserID)
+				}
+			} else if !ok || sub.OriginalAppUserID != test.expected {
+				t.Errorf("expected cached subscriber %q, got: %q", test.expected, sub.OriginalAppUserID)
+			}
+			if len(cc.fills) != 0 {
+				t.Errorf("expected no fills in flight, got: %d", len(cc.fills))
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package revenuecat
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 )
@@ -15,6 +17,12 @@ type Client struct {
 	apiURL  string
 	http    doer
 	sandbox bool
+	retry   RetryPolicy
+	flights *flightGroup
+	limiter *rateLimiter
+	breaker *circuitBreaker
+	clock   Clock
+	sleep   func(ctx context.Context, d time.Duration) error
 }
 
 type Option func(*Client)
@@ -33,10 +41,16 @@ func New(apiKey string, opts ...Option) *Client {
 			// Set a long timeout here since calls to Apple are probably involved.
 			Timeout: 10 * time.Second,
 		},
+		clock: systemClock{},
+		sleep: sleepContext,
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	// The rate limiter stays on the real clock, since it waits with real sleeps.
+	if c.breaker != nil {
+		c.breaker.now = c.clock.Now
+	}
 
 	return c
 }
@@ -48,6 +62,18 @@ func WithHTTPClient(client doer) Option {
 	}
 }
 
+// WithClock - Option to set the clock used for retries, circuit breaking and caching
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// Now returns the current time according to the client's clock.
+func (c *Client) Now() time.Time {
+	return c.clock.Now()
+}
+
 // WithAPIURL - Option to set the API URL
 func WithAPIURL(url string) Option {
 	return func(c *Client) {
@@ -62,18 +88,111 @@ func WithSandboxEnabled(enabled bool) Option {
 	}
 }
 
-func (c *Client) call(method, path string, reqBody interface{}, platform string, respBody interface{}) error {
-	var reqBodyJSON io.Reader
+func (c *Client) call(ctx context.Context, method, path string, reqBody interface{}, platform string, respBody interface{}) error {
+	var reqBodyJSON []byte
 	if reqBody != nil {
 		js, err := json.Marshal(reqBody)
 		if err != nil {
 			return fmt.Errorf("error marshaling request body: %v", err)
 		}
-		reqBodyJSON = bytes.NewBuffer(js)
+		reqBodyJSON = js
+	}
+
+	send := func() ([]byte, error) {
+		if c.breaker == nil {
+			return c.send(ctx, method, path, reqBodyJSON, platform)
+		}
+		done, err := c.breaker.allow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.send(ctx, method, path, reqBodyJSON, platform)
+		done(err)
+		return body, err
+	}
+	var body []byte
+	var err error
+	if c.flights != nil && method == http.MethodGet {
+		body, err = c.flights.do(ctx, c.flightKey(method, path, platform), send)
+	} else {
+		body, err = send()
+	}
+	if err != nil {
+		return err
+	}
+
+	if respBody == nil {
+		// Expecting an empty body.
+		return nil
+	}
+	err = json.Unmarshal(body, respBody)
+	if err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// send makes the request, retrying it according to the retry policy, and returns the response body.
+func (c *Client) send(ctx context.Context, method, path string, reqBody []byte, platform string) ([]byte, error) {
+	var resp *http.Response
+	category := rateCategory(method, path)
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, method, path, reqBody, platform)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %v", err)
+		}
+
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx, c.sleep, category); err != nil {
+				return nil, fmt.Errorf("error making request: %w", err)
+			}
+		}
+		resp, err = c.http.Do(req)
+		if c.limiter != nil {
+			c.limiter.observe(category, resp)
+		}
+		delay, retry := c.retry.retryDelay(ctx, method, attempt, resp, err, c.clock.Now())
+		if !retry {
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					// Surface the context error itself so callers can use errors.Is(err, context.Canceled).
+					return nil, fmt.Errorf("error making request: %w", ctxErr)
+				}
+				return nil, fmt.Errorf("error making request: %v", err)
+			}
+			break
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused by the next attempt.
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("error making request: %w", err)
+		}
 	}
-	req, err := http.NewRequest(method, c.apiURL+path, reqBodyJSON)
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, newError(resp)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return body, nil
+}
+
+// newRequest builds a request for a single attempt. The body is re-read from reqBody on every call,
+// so it can be replayed when the request is retried.
+func (c *Client) newRequest(ctx context.Context, method, path string, reqBody []byte, platform string) (*http.Request, error) {
+	var body io.Reader
+	if reqBody != nil {
+		body = bytes.NewReader(reqBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return nil, err
 	}
 	req.Header.Add("Authorization", "Bearer "+c.apiKey)
 	req.Header.Add("Content-Type", "application/json")
@@ -85,27 +204,26 @@ func (c *Client) call(method, path string, reqBody interface{}, platform string,
 	if c.sandbox {
 		req.Header.Add("X-Is-Sandbox", "true")
 	}
+	return req, nil
+}
 
-	resp, err := c.http.Do(req)
+// maxErrorBodySize limits how much of an error response is kept in Error.Body.
+const maxErrorBodySize = 64 << 10
+
+// newError builds an Error from a failed response. Bodies that aren't RevenueCat JSON errors,
+// such as a proxy's HTML error page, fall back to the HTTP status text.
+func newError(resp *http.Response) error {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
 	if err != nil {
-		return fmt.Errorf("error making request: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		var errResp Error
-		err = json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return err
-		}
-		return errResp
+		return fmt.Errorf("error reading error response: %w", err)
 	}
-	if respBody == nil {
-		// Expecting an empty body.
-		return nil
+	errResp := Error{
+		StatusCode: resp.StatusCode,
+		Response:   &ResponseDetails{Header: resp.Header, Body: body},
 	}
-	err = json.NewDecoder(resp.Body).Decode(respBody)
-	if err != nil {
-		return fmt.Errorf("error decoding response: %v", err)
+	if json.Unmarshal(body, &errResp) != nil || (errResp.Code == 0 && errResp.Message == "") {
+		errResp.Code = 0
+		errResp.Message = http.StatusText(resp.StatusCode)
 	}
-	return nil
+	return errResp
 }
@@ -2,14 +2,18 @@ package revenuecat
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 )
 
 type mockClient struct {
+	mu      sync.Mutex
 	request *http.Request
 	doer    func(req *http.Request) (*http.Response, error)
 }
@@ -35,7 +39,9 @@ func newMockClient(t *testing.T, statusCode int, body interface{}, returnErr err
 }
 
 func (c *mockClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
 	c.request = req
+	c.mu.Unlock()
 	return c.doer(req)
 }
 
@@ -93,3 +99,79 @@ func staticTime(t *testing.T, timeStr string) time.Time {
 	}
 	return val
 }
+
+func TestCallContextPropagated(t *testing.T) {
+	cl := newMockClient(t, 200, nil, nil)
+	rc := New("apikey")
+	rc.http = cl
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	_, err := rc.GetSubscriberContext(ctx, "123")
+	if err != nil {
+		t.Errorf("error: %v", err)
+	}
+
+	if v := cl.request.Context().Value(key{}); v != "value" {
+		t.Errorf("expected request context to carry value, got: %v", v)
+	}
+}
+
+func TestCallContextCanceled(t *testing.T) {
+	cl := &mockClient{}
+	cl.doer = func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	rc := New("apikey")
+	rc.http = cl
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := rc.GrantEntitlementContext(ctx, "123", "all", Monthly, time.Time{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestCallContextDeadlineExceeded(t *testing.T) {
+	cl := &mockClient{}
+	cl.doer = func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, errors.New("transport closed")
+	}
+	rc := New("apikey")
+	rc.http = cl
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := rc.DeleteSubscriberContext(ctx, "123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+// errReader fails every read with err, like a response body whose request context was cancelled.
+type errReader struct{ err error }
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func TestCallContextCanceledReadingBody(t *testing.T) {
+	for _, status := range []int{200, 500} {
+		cl := &mockClient{}
+		cl.doer = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: status, Body: ioutil.NopCloser(errReader{context.Canceled})}, nil
+		}
+		rc := New("apikey")
+		rc.http = cl
+
+		_, err := rc.GetSubscriber("123")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("status %d: expected context.Canceled, got: %v", status, err)
+		}
+	}
+}
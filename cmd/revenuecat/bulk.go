@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/mhemmings/revenuecat/v2/bulk"
+)
+
+func runBulk(e *env, args []string) error {
+	fs := e.flags()
+	format := fs.String("format", "", "input format: csv or jsonl (default from the file extension)")
+	op := fs.String("op", "", "op for rows without one: grant, revoke, attributes or delete")
+	concurrency := fs.Int("concurrency", 4, "number of rows to run at the same time")
+	rate := fs.Float64("rate", 0, "maximum rows to start per second (default unlimited)")
+	checkpoint := fs.String("checkpoint", "", "file to record succeeded rows in, so a rerun skips them")
+	resultsPath := fs.String("results", "", "file to append per-row results to as JSON lines")
+	yes := fs.Bool("yes", false, "confirm delete rows, which cannot be undone")
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	rows, err := readRows(args[0], *format)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if rows[i].Op == "" {
+			rows[i].Op = bulk.Op(*op)
+		}
+		if rows[i].Op == bulk.OpDelete && !*yes {
+			return usageError{"input deletes subscribers, which cannot be undone, pass -yes to confirm"}
+		}
+	}
+
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	opts := []bulk.Option{bulk.WithConcurrency(*concurrency), bulk.WithRate(*rate)}
+	if *checkpoint != "" {
+		opts = append(opts, bulk.WithCheckpoint(*checkpoint))
+	}
+	var results []io.Writer
+	if e.output == "json" {
+		results = append(results, e.stdout)
+	}
+	if *resultsPath != "" {
+		f, err := os.OpenFile(*resultsPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening results: %v", err)
+		}
+		defer f.Close()
+		results = append(results, f)
+	}
+	if len(results) > 0 {
+		opts = append(opts, bulk.WithResults(io.MultiWriter(results...)))
+	}
+
+	res, summary, runErr := bulk.NewExecutor(rc, opts...).Run(e.ctx, rows)
+	if e.output == "table" {
+		w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "LINE\tOP\tUSER ID\tSTATUS\tERROR")
+		for _, r := range res {
+			msg := r.Error
+			if msg == "" {
+				msg = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", r.Line, r.Op, r.UserID, r.Status, msg)
+		}
+		w.Flush()
+	}
+	fmt.Fprintf(e.stderr, "%d ok, %d failed, %d skipped, %d not run\n",
+		summary.OK, summary.Failed, summary.Skipped, len(rows)-len(res))
+	if runErr != nil {
+		return runErr
+	}
+	if summary.Failed > 0 {
+		return errors.New("some rows failed")
+	}
+	return nil
+}
+
+// readRows reads the rows in path, or stdin if path is "-".
+func readRows(path, format string) ([]bulk.Row, error) {
+	if format == "" {
+		switch filepath.Ext(path) {
+		case ".jsonl", ".ndjson":
+			format = "jsonl"
+		default:
+			format = "csv"
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	switch format {
+	case "csv":
+		return bulk.ReadCSV(r)
+	case "jsonl":
+		return bulk.ReadJSONL(r)
+	default:
+		return nil, usageError{fmt.Sprintf("invalid format %q", format)}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mhemmings/revenuecat/v2/revenuecattest"
+)
+
+func TestBulk(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	srv.Client().GetSubscriber("789")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "rows.csv")
+	csv := "op,user_id,entitlement,duration\ngrant,123,premium,monthly\n,456,premium,yearly\ndelete,789,,\n"
+	if err := ioutil.WriteFile(input, []byte(csv), 0644); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	code, _, stderr := runCLI(t, srv, "bulk", "-op", "grant", input)
+	if code != 2 || !strings.Contains(stderr, "-yes") {
+		t.Fatalf("expected usage error without -yes, got %d: %s", code, stderr)
+	}
+
+	checkpoint := filepath.Join(dir, "checkpoint")
+	code, stdout, stderr := runCLI(t, srv, "bulk", "-op", "grant", "-yes", "-checkpoint", checkpoint, input)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "3 ok, 0 failed") || !strings.Contains(stdout, "STATUS") {
+		t.Errorf("unexpected output: %s\n%s", stdout, stderr)
+	}
+	for _, id := range []string{"123", "456"} {
+		if sub, _ := srv.Subscriber(id); !sub.IsEntitledTo("premium") {
+			t.Errorf("expected %s to be granted premium", id)
+		}
+	}
+	if _, ok := srv.Subscriber("789"); ok {
+		t.Error("expected subscriber to be deleted")
+	}
+
+	code, _, stderr = runCLI(t, srv, "bulk", "-op", "grant", "-yes", "-checkpoint", checkpoint, input)
+	if code != 0 || !strings.Contains(stderr, "0 ok, 0 failed, 3 skipped") {
+		t.Errorf("expected every row to be skipped, got %d: %s", code, stderr)
+	}
+}
+
+func TestBulkFailures(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	input := filepath.Join(t.TempDir(), "rows.jsonl")
+	jsonl := `{"op":"grant","user_id":"123","entitlement":"premium","duration":"monthly"}
+{"op":"revoke","user_id":"456"}
+`
+	if err := ioutil.WriteFile(input, []byte(jsonl), 0644); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	code, stdout, stderr := runCLI(t, srv, "bulk", "-output", "json", input)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"status":"ok"`) || !strings.Contains(stdout, `"status":"failed"`) {
+		t.Errorf("unexpected results: %s", stdout)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+var durations = map[revenuecat.Duration]bool{
+	revenuecat.Daily:      true,
+	revenuecat.Weekly:     true,
+	revenuecat.Monthly:    true,
+	revenuecat.TwoMonth:   true,
+	revenuecat.ThreeMonth: true,
+	revenuecat.SixMonth:   true,
+	revenuecat.Yearly:     true,
+	revenuecat.Lifetime:   true,
+}
+
+func runGet(e *env, args []string) error {
+	fs := e.flags()
+	platform := fs.String("platform", "", "platform to update the subscriber's last_seen for (ios, android, macos or uikitformac)")
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.GetSubscriberWithPlatformContext(e.ctx, args[0], *platform)
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runGrant(e *env, args []string) error {
+	fs := e.flags()
+	start := fs.String("start", "", "start time of the grant in RFC 3339 format (default now)")
+	args, err := e.parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	duration := revenuecat.Duration(args[2])
+	if !durations[duration] {
+		return usageError{fmt.Sprintf("invalid duration %q", args[2])}
+	}
+	var startTime time.Time
+	if *start != "" {
+		startTime, err = time.Parse(time.RFC3339, *start)
+		if err != nil {
+			return usageError{fmt.Sprintf("invalid start time %q", *start)}
+		}
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.GrantEntitlementContext(e.ctx, args[0], args[1], duration, startTime)
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runRevoke(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.RevokeEntitlementContext(e.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runSetAttributes(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	attributes := make(map[string]revenuecat.SubscriberAttribute, len(args)-1)
+	for _, arg := range args[1:] {
+		i := strings.Index(arg, "=")
+		if i <= 0 {
+			return usageError{fmt.Sprintf("invalid attribute %q, expected key=value", arg)}
+		}
+		// An empty value deletes the attribute.
+		attributes[arg[:i]] = revenuecat.SubscriberAttribute{Value: arg[i+1:]}
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := rc.UpdateSubscriberAttributesContext(e.ctx, args[0], attributes); err != nil {
+		return err
+	}
+	return e.printResult(args[0], fmt.Sprintf("Updated %d attributes for %s", len(attributes), args[0]))
+}
+
+func runOverrideOffering(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.OverrideOfferingContext(e.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runResetOffering(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.DeleteOfferingOverrideContext(e.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runGoogleRefund(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.RefundGoogleSubscriptionContext(e.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runGoogleDefer(e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	expiry, err := time.Parse(time.RFC3339, args[2])
+	if err != nil {
+		return usageError{fmt.Sprintf("invalid expiry %q, expected RFC 3339 format", args[2])}
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	sub, err := rc.DeferGoogleSubscriptionContext(e.ctx, args[0], args[1], expiry)
+	if err != nil {
+		return err
+	}
+	return e.printSubscriber(sub)
+}
+
+func runDelete(e *env, args []string) error {
+	fs := e.flags()
+	yes := fs.Bool("yes", false, "confirm the deletion, which cannot be undone")
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		return usageError{"deleting a subscriber cannot be undone, pass -yes to confirm"}
+	}
+	rc, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := rc.DeleteSubscriberContext(e.ctx, args[0]); err != nil {
+		return err
+	}
+	return e.printResult(args[0], "Deleted "+args[0])
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// env holds the state shared by every command: its output, environment and common flags.
+type env struct {
+	ctx    context.Context
+	name   string
+	args   string
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	output     string
+	sandbox    bool
+	profile    string
+	configPath string
+}
+
+// config is the config file, which holds named profiles.
+//
+//	{
+//	  "default_profile": "production",
+//	  "profiles": {
+//	    "production": {"api_key": "sk_..."},
+//	    "staging": {"api_key": "sk_...", "sandbox": true}
+//	  }
+//	}
+type config struct {
+	DefaultProfile string             `json:"default_profile"`
+	Profiles       map[string]profile `json:"profiles"`
+}
+
+// profile holds the settings for one RevenueCat project.
+type profile struct {
+	APIKey  string `json:"api_key"`
+	APIURL  string `json:"api_url,omitempty"`
+	Sandbox bool   `json:"sandbox,omitempty"`
+}
+
+// flags returns a FlagSet for the command with the common flags registered.
+func (e *env) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(e.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.StringVar(&e.output, "output", "table", "output format: table or json")
+	fs.BoolVar(&e.sandbox, "sandbox", false, "send requests in sandbox mode")
+	fs.StringVar(&e.profile, "profile", e.getenv("REVENUECAT_PROFILE"), "config profile to use instead of REVENUECAT_API_KEY")
+	fs.StringVar(&e.configPath, "config", e.getenv("REVENUECAT_CONFIG"), "config file (default $XDG_CONFIG_HOME/revenuecat/config.json)")
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: revenuecat %s [flags] %s\n\nflags:\n", e.name, e.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args with fs and checks that at least min and at most max positional arguments remain.
+// A max of -1 means there is no upper limit.
+func (e *env) parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		// The flag package has already printed the error and usage.
+		return nil, flag.ErrHelp
+	}
+	if e.output != "table" && e.output != "json" {
+		return nil, usageError{fmt.Sprintf("invalid output format %q", e.output)}
+	}
+	rest := fs.Args()
+	if len(rest) < min || (max >= 0 && len(rest) > max) {
+		return nil, usageError{"wrong number of arguments"}
+	}
+	return rest, nil
+}
+
+// client returns a client for the selected profile. Without -profile, REVENUECAT_API_KEY is used
+// before falling back to the config file's default profile.
+func (e *env) client() (*revenuecat.Client, error) {
+	p, err := e.resolveProfile()
+	if err != nil {
+		return nil, err
+	}
+	opts := []revenuecat.Option{
+		revenuecat.WithSandboxEnabled(e.sandbox || p.Sandbox),
+	}
+	if p.APIURL != "" {
+		opts = append(opts, revenuecat.WithAPIURL(p.APIURL))
+	}
+	return revenuecat.New(p.APIKey, opts...), nil
+}
+
+func (e *env) resolveProfile() (profile, error) {
+	if e.profile == "" {
+		if key := e.getenv("REVENUECAT_API_KEY"); key != "" {
+			return profile{APIKey: key, APIURL: e.getenv("REVENUECAT_API_URL")}, nil
+		}
+	}
+
+	cfg, err := e.loadConfig()
+	if err != nil {
+		return profile{}, err
+	}
+	name := e.profile
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		return profile{}, errors.New("no API key: set REVENUECAT_API_KEY or a default_profile in the config file")
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	if p.APIKey == "" {
+		return profile{}, fmt.Errorf("profile %q has no api_key", name)
+	}
+	return p, nil
+}
+
+func (e *env) loadConfig() (config, error) {
+	path := e.configPath
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return config{}, errors.New("no API key: set REVENUECAT_API_KEY or -config")
+		}
+		path = filepath.Join(dir, "revenuecat", "config.json")
+	}
+
+	var cfg config
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit && e.profile == "" {
+		return cfg, errors.New("no API key: set REVENUECAT_API_KEY or create " + path)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config: %v", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error decoding config %s: %v", path, err)
+	}
+	return cfg, nil
+}
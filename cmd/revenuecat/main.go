@@ -0,0 +1,121 @@
+// Command revenuecat inspects and updates RevenueCat subscribers from the command line.
+//
+// Usage:
+//
+//	revenuecat <command> [flags] [arguments]
+//
+// The API key is read from the REVENUECAT_API_KEY environment variable, or from a profile in the
+// config file. Run "revenuecat help" for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+)
+
+// command is a single revenuecat subcommand.
+type command struct {
+	args    string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"get":               {"<user-id>", "get a subscriber", runGet},
+	"grant":             {"<user-id> <entitlement> <duration>", "grant a promotional entitlement", runGrant},
+	"revoke":            {"<user-id> <entitlement>", "revoke promotional entitlements", runRevoke},
+	"set-attributes":    {"<user-id> <key=value>...", "update subscriber attributes", runSetAttributes},
+	"override-offering": {"<user-id> <offering-uuid>", "override the current offering", runOverrideOffering},
+	"reset-offering":    {"<user-id>", "reset the offering override", runResetOffering},
+	"google-refund":     {"<user-id> <product-id>", "refund and revoke a Google subscription", runGoogleRefund},
+	"google-defer":      {"<user-id> <product-id> <expiry>", "defer a Google subscription to a later date", runGoogleDefer},
+	"delete":            {"<user-id>", "permanently delete a subscriber", runDelete},
+	"bulk":              {"<file>", "run grant, revoke, attributes or delete for every row of a CSV or JSON lines file", runBulk},
+}
+
+// usageError is returned for invalid arguments, which exit with status 2.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	cancel()
+	os.Exit(code)
+}
+
+// run runs the command line in args and returns the exit status.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "revenuecat: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	e := &env{
+		ctx:    ctx,
+		name:   args[0],
+		args:   cmd.args,
+		stdout: stdout,
+		stderr: stderr,
+		getenv: getenv,
+	}
+	err := cmd.run(e, args[1:])
+	var uerr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &uerr):
+		fmt.Fprintf(stderr, "revenuecat %s: %v\n", args[0], err)
+		fmt.Fprintf(stderr, "usage: revenuecat %s [flags] %s\n", args[0], cmd.args)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "revenuecat %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: revenuecat <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-18s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "revenuecat <command> -h" for the flags of a command.`)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/revenuecattest"
+)
+
+// runCLI runs the command line against srv using REVENUECAT_API_KEY, and returns its exit status and output.
+func runCLI(t *testing.T, srv *revenuecattest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	vars := map[string]string{
+		"REVENUECAT_API_KEY": "test_api_key",
+		"REVENUECAT_API_URL": srv.APIURL(),
+	}
+	return runCLIEnv(t, vars, args...)
+}
+
+func runCLIEnv(t *testing.T, vars map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr, func(key string) string { return vars[key] })
+	return code, stdout.String(), stderr.String()
+}
+
+func TestGetTable(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	if _, err := srv.Client().GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	code, stdout, stderr := runCLI(t, srv, "get", "123")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{"User ID:", "123", "ENTITLEMENT", "premium", "rc_promo_premium_monthly", "promotional"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestGrantJSON(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(t, srv, "grant", "-output", "json", "123", "premium", "yearly")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var sub revenuecat.Subscriber
+	if err := json.Unmarshal([]byte(stdout), &sub); err != nil {
+		t.Fatalf("error decoding output: %v\n%s", err, stdout)
+	}
+	if !sub.IsEntitledTo("premium") {
+		t.Errorf("expected premium entitlement, got: %+v", sub.Entitlements)
+	}
+
+	code, _, _ = runCLI(t, srv, "revoke", "123", "premium")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if sub, _ := srv.Subscriber("123"); sub.IsEntitledTo("premium") {
+		t.Error("expected entitlement to be revoked")
+	}
+}
+
+func TestGrantInvalidDuration(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	code, _, stderr := runCLI(t, srv, "grant", "123", "premium", "fortnight")
+	if code != 2 || !strings.Contains(stderr, "invalid duration") {
+		t.Errorf("expected usage error, got %d: %s", code, stderr)
+	}
+}
+
+func TestSetAttributes(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	code, stdout, stderr := runCLI(t, srv, "set-attributes", "123", "$email=a@example.com", "plan=gold")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Updated 2 attributes") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	sub, _ := srv.Subscriber("123")
+	if sub.SubscriberAttributes["plan"].Value != "gold" || sub.SubscriberAttributes["$email"].Value != "a@example.com" {
+		t.Errorf("unexpected attributes: %+v", sub.SubscriberAttributes)
+	}
+
+	code, _, _ = runCLI(t, srv, "set-attributes", "123", "novalue")
+	if code != 2 {
+		t.Errorf("expected exit 2 for invalid attribute, got %d", code)
+	}
+}
+
+func TestOfferings(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	if code, _, stderr := runCLI(t, srv, "override-offering", "123", "offering-uuid"); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if got := srv.OfferingOverride("123"); got != "offering-uuid" {
+		t.Errorf("expected override, got %q", got)
+	}
+	if code, _, stderr := runCLI(t, srv, "reset-offering", "123"); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if got := srv.OfferingOverride("123"); got != "" {
+		t.Errorf("expected override to be reset, got %q", got)
+	}
+}
+
+func TestDeleteRequiresConfirmation(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	srv.Client().GetSubscriber("123")
+
+	code, _, stderr := runCLI(t, srv, "delete", "123")
+	if code != 2 || !strings.Contains(stderr, "-yes") {
+		t.Errorf("expected usage error, got %d: %s", code, stderr)
+	}
+	if _, ok := srv.Subscriber("123"); !ok {
+		t.Fatal("expected subscriber not to be deleted")
+	}
+
+	code, stdout, stderr := runCLI(t, srv, "delete", "-yes", "-output", "json", "123")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"status": "ok"`) {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	if _, ok := srv.Subscriber("123"); ok {
+		t.Error("expected subscriber to be deleted")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+
+	code, _, stderr := runCLI(t, srv, "google-refund", "123", "unknown")
+	if code != 1 || stderr == "" {
+		t.Errorf("expected exit 1 with error, got %d: %s", code, stderr)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	code, _, stderr := runCLIEnv(t, nil, "frobnicate")
+	if code != 2 || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("expected usage error, got %d: %s", code, stderr)
+	}
+}
+
+func TestSandboxFlag(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	var sandbox string
+	srv.Inject(revenuecattest.Rule{}, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		sandbox = r.Header.Get("X-Is-Sandbox")
+		next.ServeHTTP(w, r)
+	})
+
+	if code, _, stderr := runCLI(t, srv, "get", "-sandbox", "123"); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if sandbox != "true" {
+		t.Errorf("expected sandbox header, got %q", sandbox)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	srv := revenuecattest.NewServer()
+	defer srv.Close()
+	var auth string
+	srv.Inject(revenuecattest.Rule{}, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		auth = r.Header.Get("Authorization")
+		next.ServeHTTP(w, r)
+	})
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := fmt.Sprintf(`{
+  "default_profile": "production",
+  "profiles": {
+    "production": {"api_key": "production_key", "api_url": %[1]q},
+    "staging": {"api_key": "staging_key", "api_url": %[1]q}
+  }
+}`, srv.APIURL())
+	if err := ioutil.WriteFile(path, []byte(cfg), 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		args []string
+		auth string
+	}{
+		{"default profile", map[string]string{"REVENUECAT_CONFIG": path}, nil, "Bearer production_key"},
+		{"profile flag", map[string]string{"REVENUECAT_CONFIG": path}, []string{"-profile", "staging"}, "Bearer staging_key"},
+		{"profile env", map[string]string{"REVENUECAT_CONFIG": path, "REVENUECAT_PROFILE": "staging"}, nil, "Bearer staging_key"},
+		{"profile flag beats api key", map[string]string{"REVENUECAT_CONFIG": path, "REVENUECAT_API_KEY": "env_key"}, []string{"-profile", "staging"}, "Bearer staging_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(append([]string{"get"}, tt.args...), "123")
+			code, _, stderr := runCLIEnv(t, tt.vars, args...)
+			if code != 0 {
+				t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+			}
+			if auth != tt.auth {
+				t.Errorf("expected %q, got %q", tt.auth, auth)
+			}
+		})
+	}
+
+	code, _, stderr := runCLIEnv(t, map[string]string{"REVENUECAT_CONFIG": path}, "get", "-profile", "missing", "123")
+	if code != 1 || !strings.Contains(stderr, `unknown profile "missing"`) {
+		t.Errorf("expected unknown profile error, got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// printSubscriber writes sub in the selected output format.
+func (e *env) printSubscriber(sub revenuecat.Subscriber) error {
+	if e.output == "json" {
+		return e.printJSON(sub)
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "User ID:\t%s\n", sub.OriginalAppUserID)
+	fmt.Fprintf(w, "First seen:\t%s\n", formatTime(sub.FirstSeen))
+	fmt.Fprintf(w, "Last seen:\t%s\n", formatTime(sub.LastSeen))
+	if sub.ManagementURL != nil {
+		fmt.Fprintf(w, "Manage:\t%s\n", *sub.ManagementURL)
+	}
+
+	if len(sub.Entitlements) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "ENTITLEMENT\tPRODUCT\tPURCHASED\tEXPIRES\tACTIVE")
+		for _, id := range sortedKeys(sub.Entitlements) {
+			ent := sub.Entitlements[id]
+			expires := "never"
+			if !ent.IsLifetime() {
+				expires = formatTime(*ent.ExpiresDate)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, ent.ProductIdentifier, formatTime(ent.PurchaseDate),
+				expires, yesNo(sub.IsEntitledTo(id)))
+		}
+	}
+
+	if len(sub.Subscriptions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "SUBSCRIPTION\tSTORE\tPERIOD\tPURCHASED\tEXPIRES\tSTATUS\tSANDBOX\tNOTES")
+		for _, id := range sortedKeys(sub.Subscriptions) {
+			s := sub.Subscriptions[id]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, s.Store, s.PeriodType, formatTime(s.PurchaseDate),
+				formatTimePtr(s.ExpiresDate), s.Status(), yesNo(s.IsSandbox), subscriptionNotes(s))
+		}
+	}
+
+	if len(sub.NonSubscriptions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "NON-SUBSCRIPTION\tID\tSTORE\tPURCHASED\tSANDBOX")
+		for _, id := range sortedKeys(sub.NonSubscriptions) {
+			for _, p := range sub.NonSubscriptions[id] {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, p.ID, p.Store, formatTime(p.PurchaseDate), yesNo(p.IsSandbox))
+			}
+		}
+	}
+
+	if len(sub.SubscriberAttributes) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "ATTRIBUTE\tVALUE\tUPDATED")
+		for _, key := range sortedKeys(sub.SubscriberAttributes) {
+			attr := sub.SubscriberAttributes[key]
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key, attr.Value, formatTime(attr.UpdatedAt))
+		}
+	}
+	return w.Flush()
+}
+
+// printResult writes the result of a command that doesn't return a subscriber.
+func (e *env) printResult(userID, message string) error {
+	if e.output == "json" {
+		return e.printJSON(struct {
+			UserID string `json:"user_id"`
+			Status string `json:"status"`
+		}{userID, "ok"})
+	}
+	_, err := fmt.Fprintln(e.stdout, message)
+	return err
+}
+
+func (e *env) printJSON(v interface{}) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func subscriptionNotes(s revenuecat.Subscription) string {
+	var notes []string
+	if s.UnsubscribeDetectedAt != nil {
+		notes = append(notes, "unsubscribed")
+	}
+	if s.BillingIssuesDetectedAt != nil {
+		notes = append(notes, "billing issue")
+	}
+	if s.GracePeriodExpiresDate != nil {
+		notes = append(notes, "grace period until "+formatTime(*s.GracePeriodExpiresDate))
+	}
+	if s.AutoResumeDate != nil {
+		notes = append(notes, "paused until "+formatTime(*s.AutoResumeDate))
+	}
+	if s.RefundedAt != nil {
+		notes = append(notes, "refunded")
+	}
+	if len(notes) == 0 {
+		return "-"
+	}
+	return strings.Join(notes, ", ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return formatTime(*t)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// sortedKeys returns the keys of m, which must be a map with string keys, in order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]revenuecat.Entitlement:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]revenuecat.Subscription:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string][]revenuecat.NonSubscription:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]revenuecat.SubscriberAttribute:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package revenuecat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// WithRequestCoalescing - Option to share one round-trip between concurrent identical GET requests
+//
+// While a GET request is in flight, identical requests (same path, platform and sandbox mode) wait for it
+// and receive its response instead of making their own. Each caller still decodes its own copy of the
+// response, so a returned Subscriber can be modified without affecting other callers.
+func WithRequestCoalescing(enabled bool) Option {
+	return func(c *Client) {
+		if enabled {
+			c.flights = &flightGroup{}
+		} else {
+			c.flights = nil
+		}
+	}
+}
+
+// flightKey identifies requests that can share a response. The API key is implied by the Client.
+func (c *Client) flightKey(method, path, platform string) string {
+	return method + " " + c.apiURL + path + "\x00" + platform + "\x00" + strconv.FormatBool(c.sandbox)
+}
+
+// flightGroup deduplicates concurrent calls with the same key.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// do calls fn unless a call with the same key is already in flight, in which case it waits for that
+// call's result. If the shared call was cancelled by its own caller's context while ctx is still live,
+// fn is called again rather than returning someone else's cancellation.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error making request: %w", ctx.Err())
+		}
+		if isContextError(f.err) && ctx.Err() == nil {
+			return fn()
+		}
+		return f.body, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.body, f.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(f.done)
+	return f.body, f.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
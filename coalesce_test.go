@@ -0,0 +1,146 @@
+package revenuecat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// newBlockingClient returns a mockClient whose requests block until release is closed. Each request
+// sends on the returned channel as it starts.
+func newBlockingClient(t *testing.T, release chan struct{}) (*mockClient, *int, <-chan struct{}) {
+	t.Helper()
+	var mu sync.Mutex
+	var count int
+	started := make(chan struct{}, 10)
+	c := &mockClient{}
+	c.doer = func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"subscriber":{"entitlements":{"premium":{}}}}`))),
+		}, nil
+	}
+	return c, &count, started
+}
+
+// waitingContext marks wg done the first time Done is called. A follower only calls Done once it is
+// waiting on the in-flight request, so wg.Wait returns when every follower has joined it.
+type waitingContext struct {
+	context.Context
+	once sync.Once
+	wg   *sync.WaitGroup
+}
+
+func newWaitingContext(wg *sync.WaitGroup) *waitingContext {
+	wg.Add(1)
+	return &waitingContext{Context: context.Background(), wg: wg}
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(c.wg.Done)
+	return c.Context.Done()
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	release := make(chan struct{})
+	cl, count, started := newBlockingClient(t, release)
+	rc := New("apikey", WithRequestCoalescing(true))
+	rc.http = cl
+
+	const n = 10
+	subs := make([]Subscriber, n)
+	var wg, waiting sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		ctx := context.Context(context.Background())
+		if i > 0 {
+			ctx = newWaitingContext(&waiting)
+		}
+		go func(i int) {
+			defer wg.Done()
+			sub, err := rc.GetSubscriberContext(ctx, "123")
+			if err != nil {
+				t.Errorf("error: %v", err)
+			}
+			subs[i] = sub
+		}(i)
+		if i == 0 {
+			<-started
+		}
+	}
+	waiting.Wait()
+	close(release)
+	wg.Wait()
+
+	if *count != 1 {
+		t.Errorf("expected 1 request, got: %d", *count)
+	}
+	delete(subs[0].Entitlements, "premium")
+	if _, ok := subs[1].Entitlements["premium"]; !ok {
+		t.Error("expected each caller to get its own copy of the subscriber")
+	}
+}
+
+func TestRequestCoalescingKey(t *testing.T) {
+	rc := New("apikey", WithRequestCoalescing(true))
+	sandbox := New("apikey", WithRequestCoalescing(true), WithSandboxEnabled(true))
+
+	keys := map[string]bool{
+		rc.flightKey("GET", "subscribers/123", ""):      true,
+		rc.flightKey("GET", "subscribers/123", "ios"):   true,
+		rc.flightKey("GET", "subscribers/456", ""):      true,
+		sandbox.flightKey("GET", "subscribers/123", ""): true,
+	}
+	if len(keys) != 4 {
+		t.Errorf("expected distinct keys for different users, platforms and sandbox modes, got: %v", keys)
+	}
+}
+
+func TestRequestCoalescingLeaderCanceled(t *testing.T) {
+	release := make(chan struct{})
+	cl, count, started := newBlockingClient(t, release)
+	rc := New("apikey", WithRequestCoalescing(true))
+	rc.http = cl
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := rc.GetSubscriberContext(ctx, "123")
+		leaderErr <- err
+	}()
+	<-started
+
+	var waiting sync.WaitGroup
+	followerCtx := newWaitingContext(&waiting)
+	followerErr := make(chan error)
+	go func() {
+		_, err := rc.GetSubscriberContext(followerCtx, "123")
+		followerErr <- err
+	}()
+	waiting.Wait()
+
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected leader to be canceled, got: %v", err)
+	}
+	close(release)
+	if err := <-followerErr; err != nil {
+		t.Errorf("expected follower to retry on its own, got: %v", err)
+	}
+	if *count != 2 {
+		t.Errorf("expected 2 requests, got: %d", *count)
+	}
+}
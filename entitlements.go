@@ -0,0 +1,87 @@
+package revenuecat
+
+import "time"
+
+// EntitlementReason explains the result of evaluating an entitlement.
+type EntitlementReason string
+
+const (
+	// EntitlementActive means the entitlement hasn't expired, or is a lifetime entitlement.
+	EntitlementActive EntitlementReason = "active"
+	// EntitlementInGracePeriod means the entitlement has expired but the store is still retrying the renewal.
+	EntitlementInGracePeriod EntitlementReason = "in_grace_period"
+	// EntitlementBillingIssue means the store failed to charge for the subscription backing the entitlement.
+	EntitlementBillingIssue EntitlementReason = "billing_issue"
+	// EntitlementExpired means the entitlement has expired.
+	EntitlementExpired EntitlementReason = "expired"
+	// EntitlementRefunded means the purchase backing the entitlement was refunded.
+	EntitlementRefunded EntitlementReason = "refunded"
+	// EntitlementPaused means the subscription backing the entitlement is paused.
+	EntitlementPaused EntitlementReason = "paused"
+	// EntitlementMissing means the subscriber has never had the entitlement.
+	EntitlementMissing EntitlementReason = "missing"
+)
+
+// EntitlementEvaluator decides whether a subscriber has access to an entitlement, taking the state of the
+// subscription backing it into account. Refunded purchases never grant access; otherwise the zero value
+// grants access when IsEntitledTo does, except during a grace period, which it doesn't honor.
+type EntitlementEvaluator struct {
+	// HonorGracePeriod keeps access after expiry until the grace period ends, while the store retries the renewal.
+	HonorGracePeriod bool
+	// DenyBillingIssues removes access as soon as a billing issue is detected, even before expiry.
+	DenyBillingIssues bool
+}
+
+// DefaultEntitlementEvaluator honors store grace periods and allows access during billing issues.
+var DefaultEntitlementEvaluator = EntitlementEvaluator{
+	HonorGracePeriod: true,
+}
+
+// Evaluate reports whether sub has access to entitlement, and why.
+// A reason of EntitlementBillingIssue or EntitlementInGracePeriod can come with either answer, depending on the policy.
+func (ev EntitlementEvaluator) Evaluate(sub Subscriber, entitlement string) (bool, EntitlementReason) {
+	return ev.EvaluateAt(sub, entitlement, time.Now())
+}
+
+// EvaluateAt is like Evaluate but evaluates the entitlement at now instead of the current time.
+// Pass sub.RequestDate to use the server's time.
+func (ev EntitlementEvaluator) EvaluateAt(sub Subscriber, entitlement string, now time.Time) (bool, EntitlementReason) {
+	ent, ok := sub.Entitlements[entitlement]
+	if !ok {
+		return false, EntitlementMissing
+	}
+	subscription, hasSubscription := sub.Subscriptions[ent.ProductIdentifier]
+	if hasSubscription && subscription.RefundedAt != nil && !subscription.RefundedAt.After(now) {
+		return false, EntitlementRefunded
+	}
+	if ent.IsLifetime() {
+		return true, EntitlementActive
+	}
+
+	grace := ent.GracePeriodExpiresDate
+	if grace == nil && hasSubscription {
+		grace = subscription.GracePeriodExpiresDate
+	}
+	billingIssue := hasSubscription && subscription.BillingIssuesDetectedAt != nil
+	if !ent.ExpiresDate.Before(now) {
+		switch {
+		case billingIssue && grace != nil:
+			// RevenueCat extends the expiry to the end of the grace period while the store retries.
+			return ev.HonorGracePeriod && !ev.DenyBillingIssues, EntitlementInGracePeriod
+		case billingIssue:
+			return !ev.DenyBillingIssues, EntitlementBillingIssue
+		}
+		return true, EntitlementActive
+	}
+
+	if grace != nil && now.Before(*grace) {
+		if ev.DenyBillingIssues {
+			return false, EntitlementBillingIssue
+		}
+		return ev.HonorGracePeriod, EntitlementInGracePeriod
+	}
+	if hasSubscription && subscription.AutoResumeDate != nil && now.Before(*subscription.AutoResumeDate) {
+		return false, EntitlementPaused
+	}
+	return false, EntitlementExpired
+}
@@ -0,0 +1,133 @@
+package revenuecat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntitlementEvaluator(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	subscriber := func(ent Entitlement, sub Subscription) Subscriber {
+		ent.ProductIdentifier = "monthly"
+		return Subscriber{
+			Entitlements:  map[string]Entitlement{"premium": ent},
+			Subscriptions: map[string]Subscription{"monthly": sub},
+		}
+	}
+	deny := EntitlementEvaluator{HonorGracePeriod: true, DenyBillingIssues: true}
+
+	tests := []struct {
+		name      string
+		evaluator EntitlementEvaluator
+		sub       Subscriber
+		entitled  bool
+		reason    EntitlementReason
+	}{
+		{
+			name:   "missing",
+			sub:    Subscriber{},
+			reason: EntitlementMissing,
+		},
+		{
+			name:     "active",
+			sub:      subscriber(Entitlement{ExpiresDate: &future}, Subscription{}),
+			entitled: true,
+			reason:   EntitlementActive,
+		},
+		{
+			name:     "lifetime",
+			sub:      subscriber(Entitlement{}, Subscription{}),
+			entitled: true,
+			reason:   EntitlementActive,
+		},
+		{
+			name:   "expired",
+			sub:    subscriber(Entitlement{ExpiresDate: &past}, Subscription{}),
+			reason: EntitlementExpired,
+		},
+		{
+			name:   "refunded",
+			sub:    subscriber(Entitlement{ExpiresDate: &future}, Subscription{RefundedAt: &past}),
+			reason: EntitlementRefunded,
+		},
+		{
+			name:   "paused",
+			sub:    subscriber(Entitlement{ExpiresDate: &past}, Subscription{AutoResumeDate: &future}),
+			reason: EntitlementPaused,
+		},
+		{
+			name:     "billing issue allowed",
+			sub:      subscriber(Entitlement{ExpiresDate: &future}, Subscription{BillingIssuesDetectedAt: &past}),
+			entitled: true,
+			reason:   EntitlementBillingIssue,
+		},
+		{
+			name:      "billing issue denied",
+			evaluator: deny,
+			sub:       subscriber(Entitlement{ExpiresDate: &future}, Subscription{BillingIssuesDetectedAt: &past}),
+			reason:    EntitlementBillingIssue,
+		},
+		{
+			name:      "grace period honored",
+			evaluator: DefaultEntitlementEvaluator,
+			sub:       subscriber(Entitlement{ExpiresDate: &past, GracePeriodExpiresDate: &future}, Subscription{BillingIssuesDetectedAt: &past}),
+			entitled:  true,
+			reason:    EntitlementInGracePeriod,
+		},
+		{
+			name:      "grace period from subscription",
+			evaluator: DefaultEntitlementEvaluator,
+			sub:       subscriber(Entitlement{ExpiresDate: &past}, Subscription{GracePeriodExpiresDate: &future}),
+			entitled:  true,
+			reason:    EntitlementInGracePeriod,
+		},
+		{
+			name:      "grace period extended expiry",
+			evaluator: DefaultEntitlementEvaluator,
+			sub:       subscriber(Entitlement{ExpiresDate: &future}, Subscription{ExpiresDate: &future, BillingIssuesDetectedAt: &past, GracePeriodExpiresDate: &future}),
+			entitled:  true,
+			reason:    EntitlementInGracePeriod,
+		},
+		{
+			name:   "grace period extended expiry not honored",
+			sub:    subscriber(Entitlement{ExpiresDate: &future}, Subscription{ExpiresDate: &future, BillingIssuesDetectedAt: &past, GracePeriodExpiresDate: &future}),
+			reason: EntitlementInGracePeriod,
+		},
+		{
+			name:      "grace period extended expiry with billing issues denied",
+			evaluator: deny,
+			sub:       subscriber(Entitlement{ExpiresDate: &future}, Subscription{ExpiresDate: &future, BillingIssuesDetectedAt: &past, GracePeriodExpiresDate: &future}),
+			reason:    EntitlementInGracePeriod,
+		},
+		{
+			name:   "grace period not honored",
+			sub:    subscriber(Entitlement{ExpiresDate: &past, GracePeriodExpiresDate: &future}, Subscription{}),
+			reason: EntitlementInGracePeriod,
+		},
+		{
+			name:      "grace period with billing issues denied",
+			evaluator: deny,
+			sub:       subscriber(Entitlement{ExpiresDate: &past, GracePeriodExpiresDate: &future}, Subscription{BillingIssuesDetectedAt: &past}),
+			reason:    EntitlementBillingIssue,
+		},
+		{
+			name:      "grace period over",
+			evaluator: DefaultEntitlementEvaluator,
+			sub:       subscriber(Entitlement{ExpiresDate: &past, GracePeriodExpiresDate: &past}, Subscription{BillingIssuesDetectedAt: &past}),
+			reason:    EntitlementExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entitled, reason := tt.evaluator.Evaluate(tt.sub, "premium")
+			if entitled != tt.entitled || reason != tt.reason {
+				t.Errorf("expected (%v, %s), got (%v, %s)", tt.entitled, tt.reason, entitled, reason)
+			}
+			if tt.evaluator == (EntitlementEvaluator{}) && reason != EntitlementRefunded && reason != EntitlementInGracePeriod &&
+				entitled != tt.sub.IsEntitledTo("premium") {
+				t.Errorf("expected zero evaluator to agree with IsEntitledTo")
+			}
+		})
+	}
+}
@@ -1,13 +1,143 @@
 package revenuecat
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RevenueCat backend error codes, as found in Error.Code.
+// https://docs.revenuecat.com/docs/errors#backend-error-codes
+const (
+	ErrorCodeStoreProblem                          = 7101
+	ErrorCodeCannotTransferPurchase                = 7102
+	ErrorCodeInvalidReceiptToken                   = 7103
+	ErrorCodeInvalidAppStoreSharedSecret           = 7104
+	ErrorCodeInvalidPaymentModeOrIntroPrice        = 7105
+	ErrorCodeProductIDForGoogleReceiptNotProvided  = 7106
+	ErrorCodeInvalidPlayStoreCredentials           = 7107
+	ErrorCodeInternalServerError                   = 7110
+	ErrorCodeEmptyAppUserID                        = 7220
+	ErrorCodeInvalidAuthToken                      = 7224
+	ErrorCodeInvalidAPIKey                         = 7225
+	ErrorCodeBadRequest                            = 7226
+	ErrorCodePlayStoreQuotaExceeded                = 7229
+	ErrorCodePlayStoreInvalidPackageName           = 7230
+	ErrorCodePlayStoreGenericError                 = 7231
+	ErrorCodeUserIneligibleForPromoOffer           = 7232
+	ErrorCodeInvalidAppleSubscriptionKey           = 7234
+	ErrorCodeInvalidSubscriberAttributes           = 7263
+	ErrorCodeInvalidSubscriberAttributesBody       = 7264
+	ErrorCodePurchasedProductMissingInAppleReceipt = 7712
+)
 
 // Error represents an error returned by RevenueCat
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+	// Response holds the headers and raw body of the response. It is a pointer so Error stays comparable.
+	Response *ResponseDetails `json:"-"`
+}
+
+// ResponseDetails holds the parts of a failed response that aren't decoded into an Error.
+type ResponseDetails struct {
+	// Header holds the response headers.
+	Header http.Header
+	// Body holds the raw response body, which is useful when it isn't a RevenueCat JSON error.
+	Body []byte
 }
 
 func (err Error) Error() string {
+	if err.Code == 0 && err.StatusCode != 0 {
+		return fmt.Sprintf("http %d: %s", err.StatusCode, err.Message)
+	}
 	return fmt.Sprintf("%d: %s", err.Code, err.Message)
 }
+
+// RequestID returns the request ID RevenueCat attached to the response, if any.
+func (err Error) RequestID() string {
+	if err.Response == nil {
+		return ""
+	}
+	return err.Response.Header.Get("X-Request-Id")
+}
+
+// Is reports whether target is an Error with the same Code and StatusCode.
+// Zero fields in target match anything, so errors.Is(err, Error{StatusCode: 404}) matches any 404.
+func (err Error) Is(target error) bool {
+	var t Error
+	switch v := target.(type) {
+	case Error:
+		t = v
+	case *Error:
+		if v == nil {
+			return false
+		}
+		t = *v
+	default:
+		return false
+	}
+	return (t.Code == 0 || t.Code == err.Code) &&
+		(t.StatusCode == 0 || t.StatusCode == err.StatusCode)
+}
+
+// As allows errors.As to target *Error as well as Error.
+func (err Error) As(target interface{}) bool {
+	if p, ok := target.(**Error); ok {
+		e := err
+		*p = &e
+		return true
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an Error for a missing resource, such as an unknown subscriber.
+func IsNotFound(err error) bool {
+	e, ok := asError(err)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// IsRateLimited reports whether err is an Error caused by exceeding a RevenueCat or store rate limit.
+func IsRateLimited(err error) bool {
+	e, ok := asError(err)
+	return ok && (e.StatusCode == http.StatusTooManyRequests || e.Code == ErrorCodePlayStoreQuotaExceeded)
+}
+
+// IsInvalidReceipt reports whether err is an Error caused by a receipt or fetch token the store rejected.
+func IsInvalidReceipt(err error) bool {
+	e, ok := asError(err)
+	return ok && (e.Code == ErrorCodeInvalidReceiptToken || e.Code == ErrorCodePurchasedProductMissingInAppleReceipt)
+}
+
+// IsInvalidSubscriberAttributes reports whether err is an Error caused by subscriber attributes failing validation.
+func IsInvalidSubscriberAttributes(err error) bool {
+	e, ok := asError(err)
+	return ok && (e.Code == ErrorCodeInvalidSubscriberAttributes || e.Code == ErrorCodeInvalidSubscriberAttributesBody)
+}
+
+// IsRetryable reports whether err is an Error for a temporary failure, so the same request may succeed later.
+func IsRetryable(err error) bool {
+	e, ok := asError(err)
+	if !ok {
+		return false
+	}
+	switch e.Code {
+	case ErrorCodeStoreProblem, ErrorCodeInternalServerError, ErrorCodePlayStoreQuotaExceeded:
+		return true
+	}
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func asError(err error) (Error, bool) {
+	var e Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
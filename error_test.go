@@ -1,6 +1,13 @@
 package revenuecat
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
 
 func TestError(t *testing.T) {
 	err := Error{
@@ -11,3 +18,164 @@ func TestError(t *testing.T) {
 		t.Errorf("got: %q, expected: %q", str, "123: Error message")
 	}
 }
+
+func TestErrorFromResponse(t *testing.T) {
+	cl := newMockClient(t, 400, Error{Code: 7225, Message: "Invalid API key"}, nil)
+	rc := New("apikey")
+	rc.http = cl
+
+	_, err := rc.GetSubscriber("123")
+
+	var rcErr Error
+	if !errors.As(err, &rcErr) {
+		t.Fatalf("expected Error, got: %T", err)
+	}
+	if rcErr.Code != 7225 || rcErr.Message != "Invalid API key" || rcErr.StatusCode != 400 {
+		t.Errorf("unexpected error: %+v", rcErr)
+	}
+	if body := string(rcErr.Response.Body); body != `{"code":7225,"message":"Invalid API key"}` {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if str := err.Error(); str != "7225: Invalid API key" {
+		t.Errorf("got: %q, expected: %q", str, "7225: Invalid API key")
+	}
+}
+
+func TestErrorNonJSONBody(t *testing.T) {
+	page := "<html><body>502 Bad Gateway</body></html>"
+	cl := &mockClient{}
+	cl.doer = func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 502,
+			Header:     http.Header{"X-Request-Id": {"req_123"}},
+			Body:       ioutil.NopCloser(strings.NewReader(page)),
+		}, nil
+	}
+	rc := New("apikey")
+	rc.http = cl
+
+	err := rc.DeleteSubscriber("123")
+
+	var rcErr *Error
+	if !errors.As(err, &rcErr) {
+		t.Fatalf("expected *Error, got: %T", err)
+	}
+	if rcErr.StatusCode != 502 || rcErr.Code != 0 || string(rcErr.Response.Body) != page {
+		t.Errorf("unexpected error: %+v", rcErr)
+	}
+	if id := rcErr.RequestID(); id != "req_123" {
+		t.Errorf("got request ID: %q, expected: %q", id, "req_123")
+	}
+	if str := err.Error(); str != "http 502: Bad Gateway" {
+		t.Errorf("got: %q, expected: %q", str, "http 502: Bad Gateway")
+	}
+}
+
+func TestErrorComparable(t *testing.T) {
+	var err, last error = Error{Code: 7225, StatusCode: 401}, Error{Code: 7225, StatusCode: 401}
+	if err != last {
+		t.Error("expected equal errors to compare equal")
+	}
+	if err == (Error{Code: 7224, StatusCode: 401}) {
+		t.Error("expected different errors to compare unequal")
+	}
+
+	details := &ResponseDetails{Header: http.Header{}, Body: []byte("{}")}
+	if (Error{Code: 7225, Response: details}) != (Error{Code: 7225, Response: details}) {
+		t.Error("expected errors sharing response details to compare equal")
+	}
+	if id := (Error{}).RequestID(); id != "" {
+		t.Errorf("expected no request ID without a response, got: %q", id)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", Error{Code: 7225, StatusCode: 401, Response: &ResponseDetails{Header: http.Header{}}})
+
+	tests := []struct {
+		target   error
+		expected bool
+	}{
+		{Error{Code: 7225}, true},
+		{Error{StatusCode: 401}, true},
+		{&Error{Code: 7225, StatusCode: 401}, true},
+		{Error{Code: 7224}, false},
+		{Error{StatusCode: 404}, false},
+		{errors.New("other"), false},
+	}
+	for _, test := range tests {
+		if res := errors.Is(err, test.target); res != test.expected {
+			t.Errorf("errors.Is(%v) = %v, expected %v", test.target, res, test.expected)
+		}
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		notFound     bool
+		rateLimited  bool
+		invalidRcpt  bool
+		invalidAttrs bool
+		retryable    bool
+	}{{
+		name: "nil",
+		err:  nil,
+	}, {
+		name: "other",
+		err:  errors.New("other"),
+	}, {
+		name:     "not found",
+		err:      Error{StatusCode: 404},
+		notFound: true,
+	}, {
+		name:        "rate limited",
+		err:         Error{StatusCode: 429},
+		rateLimited: true,
+		retryable:   true,
+	}, {
+		name:        "play store quota",
+		err:         Error{Code: ErrorCodePlayStoreQuotaExceeded, StatusCode: 400},
+		rateLimited: true,
+		retryable:   true,
+	}, {
+		name:        "invalid receipt",
+		err:         fmt.Errorf("wrapped: %w", Error{Code: ErrorCodeInvalidReceiptToken, StatusCode: 400}),
+		invalidRcpt: true,
+	}, {
+		name:         "invalid attributes",
+		err:          Error{Code: ErrorCodeInvalidSubscriberAttributes, StatusCode: 400},
+		invalidAttrs: true,
+	}, {
+		name:      "internal error",
+		err:       Error{Code: ErrorCodeInternalServerError, StatusCode: 500},
+		retryable: true,
+	}, {
+		name:      "bad gateway",
+		err:       Error{StatusCode: 502},
+		retryable: true,
+	}, {
+		name: "invalid api key",
+		err:  Error{Code: ErrorCodeInvalidAPIKey, StatusCode: 401},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if res := IsNotFound(test.err); res != test.notFound {
+				t.Errorf("IsNotFound: got %v, expected %v", res, test.notFound)
+			}
+			if res := IsRateLimited(test.err); res != test.rateLimited {
+				t.Errorf("IsRateLimited: got %v, expected %v", res, test.rateLimited)
+			}
+			if res := IsInvalidReceipt(test.err); res != test.invalidRcpt {
+				t.Errorf("IsInvalidReceipt: got %v, expected %v", res, test.invalidRcpt)
+			}
+			if res := IsInvalidSubscriberAttributes(test.err); res != test.invalidAttrs {
+				t.Errorf("IsInvalidSubscriberAttributes: got %v, expected %v", res, test.invalidAttrs)
+			}
+			if res := IsRetryable(test.err); res != test.retryable {
+				t.Errorf("IsRetryable: got %v, expected %v", res, test.retryable)
+			}
+		})
+	}
+}
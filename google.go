@@ -1,31 +1,40 @@
 package revenuecat
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // RefundGoogleSubscription immediately revokes access to a Google Subscription and issues a refund for the last purchase.
 // https://docs.revenuecat.com/reference#revoke-a-google-subscription
 func (c *Client) RefundGoogleSubscription(userID string, id string) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
+	return c.RefundGoogleSubscriptionContext(context.Background(), userID, id)
+}
+
+// RefundGoogleSubscriptionContext is like RefundGoogleSubscription but uses ctx for the request.
+func (c *Client) RefundGoogleSubscriptionContext(ctx context.Context, userID string, id string) (Subscriber, error) {
+	var resp subscriberResponse
 
-	err := c.call("POST", "subscribers/"+userID+"/subscriptions/"+id+"/revoke", nil, "", &resp)
-	return resp.Subscriber, err
+	err := c.call(ctx, "POST", "subscribers/"+userID+"/subscriptions/"+id+"/revoke", nil, "", &resp)
+	return resp.subscriber(), err
 }
 
 // DeferGoogleSubscription defers the purchase of a Google Subscription to a later date.
 // https://docs.revenuecat.com/reference#defer-a-google-subscription
 func (c *Client) DeferGoogleSubscription(userID string, id string, nextExpiry time.Time) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
+	return c.DeferGoogleSubscriptionContext(context.Background(), userID, id, nextExpiry)
+}
+
+// DeferGoogleSubscriptionContext is like DeferGoogleSubscription but uses ctx for the request.
+func (c *Client) DeferGoogleSubscriptionContext(ctx context.Context, userID string, id string, nextExpiry time.Time) (Subscriber, error) {
+	var resp subscriberResponse
 
 	req := struct {
 		ExpiryTime int64 `json:"expiry_time_ms,omitempty"`
 	}{
-		ExpiryTime: toMilliseconds(nextExpiry),
+		ExpiryTime: ToMilliseconds(nextExpiry),
 	}
 
-	err := c.call("POST", "subscribers/"+userID+"/subscriptions/"+id+"/defer", req, "", &resp)
-	return resp.Subscriber, err
+	err := c.call(ctx, "POST", "subscribers/"+userID+"/subscriptions/"+id+"/defer", req, "", &resp)
+	return resp.subscriber(), err
 }
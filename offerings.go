@@ -1,21 +1,29 @@
 package revenuecat
 
+import "context"
+
 // OverrideOffering overrides the current Offering for a specific user.
 // https://docs.revenuecat.com/reference#override-offering
 func (c *Client) OverrideOffering(userID string, offeringUUID string) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
-	err := c.call("POST", "subscribers/"+userID+"/offerings/"+offeringUUID+"/override", nil, "", &resp)
-	return resp.Subscriber, err
+	return c.OverrideOfferingContext(context.Background(), userID, offeringUUID)
+}
+
+// OverrideOfferingContext is like OverrideOffering but uses ctx for the request.
+func (c *Client) OverrideOfferingContext(ctx context.Context, userID string, offeringUUID string) (Subscriber, error) {
+	var resp subscriberResponse
+	err := c.call(ctx, "POST", "subscribers/"+userID+"/offerings/"+offeringUUID+"/override", nil, "", &resp)
+	return resp.subscriber(), err
 }
 
 // DeleteOfferingOverride reset the offering overrides back to the current offering for a specific user.
 // https://docs.revenuecat.com/reference#delete-offering-override
 func (c *Client) DeleteOfferingOverride(userID string) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
-	err := c.call("DELETE", "subscribers/"+userID+"/offerings/override", nil, "", &resp)
-	return resp.Subscriber, err
+	return c.DeleteOfferingOverrideContext(context.Background(), userID)
+}
+
+// DeleteOfferingOverrideContext is like DeleteOfferingOverride but uses ctx for the request.
+func (c *Client) DeleteOfferingOverrideContext(ctx context.Context, userID string) (Subscriber, error) {
+	var resp subscriberResponse
+	err := c.call(ctx, "DELETE", "subscribers/"+userID+"/offerings/override", nil, "", &resp)
+	return resp.subscriber(), err
 }
@@ -1,13 +1,19 @@
 package revenuecat
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // GrantEntitlement grants a user a promotional entitlement.
 // https://docs.revenuecat.com/reference#grant-a-promotional-entitlement
 func (c *Client) GrantEntitlement(userID string, id string, duration Duration, startTime time.Time) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
+	return c.GrantEntitlementContext(context.Background(), userID, id, duration, startTime)
+}
+
+// GrantEntitlementContext is like GrantEntitlement but uses ctx for the request.
+func (c *Client) GrantEntitlementContext(ctx context.Context, userID string, id string, duration Duration, startTime time.Time) (Subscriber, error) {
+	var resp subscriberResponse
 
 	req := struct {
 		Duration  Duration `json:"duration"`
@@ -17,20 +23,23 @@ func (c *Client) GrantEntitlement(userID string, id string, duration Duration, s
 	}
 
 	if !startTime.IsZero() {
-		req.StartTime = toMilliseconds(startTime)
+		req.StartTime = ToMilliseconds(startTime)
 	}
 
-	err := c.call("POST", "subscribers/"+userID+"/entitlements/"+id+"/promotional", req, "", &resp)
-	return resp.Subscriber, err
+	err := c.call(ctx, "POST", "subscribers/"+userID+"/entitlements/"+id+"/promotional", req, "", &resp)
+	return resp.subscriber(), err
 }
 
 // RevokeEntitlement revokes all promotional entitlements for a given entitlement identifier and app user ID.
 // https://docs.revenuecat.com/reference#revoke-promotional-entitlements
 func (c *Client) RevokeEntitlement(userID string, id string) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
+	return c.RevokeEntitlementContext(context.Background(), userID, id)
+}
+
+// RevokeEntitlementContext is like RevokeEntitlement but uses ctx for the request.
+func (c *Client) RevokeEntitlementContext(ctx context.Context, userID string, id string) (Subscriber, error) {
+	var resp subscriberResponse
 
-	err := c.call("POST", "subscribers/"+userID+"/entitlements/"+id+"/revoke_promotionals", nil, "", &resp)
-	return resp.Subscriber, err
+	err := c.call(ctx, "POST", "subscribers/"+userID+"/entitlements/"+id+"/revoke_promotionals", nil, "", &resp)
+	return resp.subscriber(), err
 }
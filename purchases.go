@@ -1,5 +1,7 @@
 package revenuecat
 
+import "context"
+
 // CreatePurchaseOptions holds the optional values for creating a purchase.
 // https://docs.revenuecat.com/reference#receipts
 type CreatePurchaseOptions struct {
@@ -17,9 +19,12 @@ type CreatePurchaseOptions struct {
 // CreatePurchase records a purchase for a user from iOS, Android, or Stripe and will create a user if they don't already exist.
 // https://docs.revenuecat.com/reference#receipts
 func (c *Client) CreatePurchase(userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
+	return c.CreatePurchaseContext(context.Background(), userID, receipt, opt)
+}
+
+// CreatePurchaseContext is like CreatePurchase but uses ctx for the request.
+func (c *Client) CreatePurchaseContext(ctx context.Context, userID string, receipt string, opt *CreatePurchaseOptions) (Subscriber, error) {
+	var resp subscriberResponse
 
 	req := struct {
 		AppUserID  string `json:"app_user_id"`
@@ -36,6 +41,6 @@ func (c *Client) CreatePurchase(userID string, receipt string, opt *CreatePurcha
 		platform = opt.Platform
 	}
 
-	err := c.call("POST", "receipts", req, platform, &resp)
-	return resp.Subscriber, err
+	err := c.call(ctx, "POST", "receipts", req, platform, &resp)
+	return resp.subscriber(), err
 }
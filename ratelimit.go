@@ -0,0 +1,187 @@
+package revenuecat
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateCategory groups endpoints that share a rate limit budget.
+type RateCategory string
+
+const (
+	// SubscriberReads covers GET requests, such as GetSubscriber.
+	SubscriberReads RateCategory = "subscriber_reads"
+	// Receipts covers CreatePurchase.
+	Receipts RateCategory = "receipts"
+	// Mutations covers every other request, such as GrantEntitlement and UpdateSubscriberAttributes.
+	Mutations RateCategory = "mutations"
+)
+
+// RateLimit is a token bucket budget. A zero Rate means the category is not limited, although it
+// still waits when the API reports that its limit has been reached.
+type RateLimit struct {
+	// Rate is the sustained number of requests per second.
+	Rate float64
+	// Burst is how many requests can be made at once after a quiet period. Defaults to Rate rounded up.
+	Burst int
+}
+
+// RateLimits holds the budget for each category of endpoint.
+type RateLimits struct {
+	SubscriberReads RateLimit
+	Receipts        RateLimit
+	Mutations       RateLimit
+}
+
+// WithRateLimits - Option to limit the request rate per category of endpoint
+//
+// Requests wait for a token from their category's bucket before being sent, or fail with ctx's error if
+// ctx is done first. Retry-After on a 429 response, and RateLimit-Remaining: 0 with RateLimit-Reset
+// (or their X- prefixed forms), pause every request in the category until the limit resets.
+func WithRateLimits(limits RateLimits) Option {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(limits)
+	}
+}
+
+type rateLimiter struct {
+	now     func() time.Time
+	buckets map[RateCategory]*tokenBucket
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		now: time.Now,
+		buckets: map[RateCategory]*tokenBucket{
+			SubscriberReads: newTokenBucket(limits.SubscriberReads),
+			Receipts:        newTokenBucket(limits.Receipts),
+			Mutations:       newTokenBucket(limits.Mutations),
+		},
+	}
+}
+
+// rateCategory returns the category of a request.
+func rateCategory(method, path string) RateCategory {
+	switch {
+	case path == "receipts":
+		return Receipts
+	case method == http.MethodGet:
+		return SubscriberReads
+	default:
+		return Mutations
+	}
+}
+
+// wait blocks until a request in category may be sent.
+func (l *rateLimiter) wait(ctx context.Context, sleep func(ctx context.Context, d time.Duration) error, category RateCategory) error {
+	b := l.buckets[category]
+	if err := sleep(ctx, b.reserve(l.now())); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// observe pauses category if resp reports that its rate limit has been reached.
+func (l *rateLimiter) observe(category RateCategory, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	now := l.now()
+	b := l.buckets[category]
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+			b.pause(now.Add(after))
+		}
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		if strings.TrimSpace(resp.Header.Get(prefix+"Remaining")) != "0" {
+			continue
+		}
+		if reset, ok := parseRateLimitReset(resp.Header.Get(prefix+"Reset"), now); ok {
+			b.pause(reset)
+		}
+	}
+}
+
+// parseRateLimitReset parses a rate limit reset header, either in seconds from now or, for
+// values too large to be a delay, as a Unix timestamp.
+func parseRateLimitReset(value string, now time.Time) (time.Time, bool) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, false
+	}
+	if secs > 1e9 {
+		return time.Unix(secs, 0), true
+	}
+	return now.Add(time.Duration(secs) * time.Second), true
+}
+
+// tokenBucket hands out reservations, letting the token count go negative so that waiting requests
+// are served in the order they arrived.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	b := &tokenBucket{rate: limit.Rate}
+	if limit.Rate > 0 {
+		b.burst = float64(limit.Burst)
+		if b.burst <= 0 {
+			b.burst = math.Max(1, math.Ceil(limit.Rate))
+		}
+		b.tokens = b.burst
+	}
+	return b
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var d time.Duration
+	if b.rate > 0 {
+		if !b.last.IsZero() && now.After(b.last) {
+			b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+	if pause := b.pausedUntil.Sub(now); pause > d {
+		d = pause
+	}
+	return d
+}
+
+// cancel returns a token whose request was never sent.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// pause holds every request until t.
+func (b *tokenBucket) pause(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.After(b.pausedUntil) {
+		b.pausedUntil = t
+	}
+}
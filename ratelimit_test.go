@@ -0,0 +1,137 @@
+package revenuecat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newRateLimitTestClient returns a client whose limiter runs on a fake clock that only moves when it sleeps.
+func newRateLimitTestClient(cl *mockClient, limits RateLimits) (*Client, *[]time.Duration) {
+	var delays []time.Duration
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rc := New("apikey", WithRateLimits(limits))
+	rc.http = cl
+	rc.limiter.now = func() time.Time { return now }
+	rc.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			delays = append(delays, d)
+			now = now.Add(d)
+		}
+		return ctx.Err()
+	}
+	return rc, &delays
+}
+
+func TestRateLimitBurstThenWait(t *testing.T) {
+	cl := newMockClient(t, 200, nil, nil)
+	rc, delays := newRateLimitTestClient(cl, RateLimits{SubscriberReads: RateLimit{Rate: 2, Burst: 2}})
+
+	for i := 0; i < 4; i++ {
+		if _, err := rc.GetSubscriber("123"); err != nil {
+			t.Fatalf("error: %v", err)
+		}
+	}
+	expected := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if len(*delays) != len(expected) || (*delays)[0] != expected[0] || (*delays)[1] != expected[1] {
+		t.Errorf("expected delays %v, got %v", expected, *delays)
+	}
+}
+
+func TestRateLimitIgnoresClock(t *testing.T) {
+	cl := newMockClient(t, 200, nil, nil)
+	rc := New("apikey", WithRateLimits(RateLimits{SubscriberReads: RateLimit{Rate: 100, Burst: 1}}),
+		WithClock(fixedClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))))
+	rc.http = cl
+	var delays []time.Duration
+	rc.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return sleepContext(ctx, d)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := rc.GetSubscriber("123"); err != nil {
+			t.Fatalf("error: %v", err)
+		}
+	}
+	// A frozen clock would never refill the bucket, so each wait would be longer than the last.
+	for _, d := range delays {
+		if d > 10*time.Millisecond {
+			t.Errorf("expected waits of at most one token, got %v", delays)
+			break
+		}
+	}
+}
+
+func TestRateLimitCategories(t *testing.T) {
+	cl := newMockClient(t, 200, nil, nil)
+	rc, delays := newRateLimitTestClient(cl, RateLimits{SubscriberReads: RateLimit{Rate: 1}})
+
+	rc.GetSubscriber("123")
+	for i := 0; i < 3; i++ {
+		rc.CreatePurchase("123", "receipt", nil)
+		rc.GrantEntitlement("123", "premium", Monthly, time.Time{})
+	}
+	if len(*delays) != 0 {
+		t.Errorf("expected other categories not to wait, got %v", *delays)
+	}
+	rc.GetSubscriber("123")
+	if len(*delays) != 1 || (*delays)[0] != time.Second {
+		t.Errorf("expected reads to wait, got %v", *delays)
+	}
+}
+
+func TestRateLimitRetryAfterPausesCategory(t *testing.T) {
+	cl, _ := newSequenceClient(t,
+		mockResponse{statusCode: 429, header: http.Header{"Retry-After": []string{"3"}}, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+	)
+	rc, delays := newRateLimitTestClient(cl, RateLimits{})
+
+	if _, err := rc.GetSubscriber("123"); !IsRateLimited(err) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	rc.GetSubscriber("123")
+	rc.GetSubscriber("123")
+	if len(*delays) != 1 || (*delays)[0] != 3*time.Second {
+		t.Errorf("expected one 3s pause, got %v", *delays)
+	}
+}
+
+func TestRateLimitRemainingHeaders(t *testing.T) {
+	cl, _ := newSequenceClient(t,
+		mockResponse{statusCode: 200, header: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"2"}}, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+	)
+	rc, delays := newRateLimitTestClient(cl, RateLimits{})
+
+	rc.DeleteSubscriber("123")
+	rc.DeleteSubscriber("123")
+	if len(*delays) != 1 || (*delays)[0] != 2*time.Second {
+		t.Errorf("expected a 2s pause, got %v", *delays)
+	}
+}
+
+func TestRateLimitContextCanceled(t *testing.T) {
+	cl := newMockClient(t, 200, nil, nil)
+	rc := New("apikey", WithRateLimits(RateLimits{SubscriberReads: RateLimit{Rate: 0.001}}))
+	rc.http = cl
+
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := rc.GetSubscriberContext(ctx, "123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// The cancelled request gives its token back.
+	if tokens := rc.limiter.buckets[SubscriberReads].tokens; tokens < -0.5 {
+		t.Errorf("expected token to be returned, got %v", tokens)
+	}
+}
@@ -0,0 +1,128 @@
+package revenuecat
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried.
+// Transport errors and 429, 500, 502, 503 and 504 responses are retried with jittered exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on every following attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts. A Retry-After value larger than MaxDelay stops retrying.
+	MaxDelay time.Duration
+	// RetryNonIdempotent enables retries for non-idempotent methods such as POST.
+	// Only enable this if a duplicate purchase, grant or attribution is acceptable.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is a reasonable RetryPolicy for most callers.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// WithRetryPolicy - Option to retry failed requests according to the given policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// retryDelay reports whether the attempt that produced resp and err should be retried, and how long to wait first.
+// now is used to resolve Retry-After dates.
+func (p RetryPolicy) retryDelay(ctx context.Context, method string, attempt int, resp *http.Response, err error, now time.Time) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt)
+	if err != nil {
+		return delay, true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+			if p.MaxDelay > 0 && after > p.MaxDelay {
+				return 0, false
+			}
+			return after, true
+		}
+		return delay, true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return delay, true
+	}
+	return 0, false
+}
+
+// backoff returns the jittered delay before the given retry. The result is between half and all of
+// BaseDelay * 2^(attempt-1), capped at MaxDelay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header value, either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
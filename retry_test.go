@@ -0,0 +1,226 @@
+package revenuecat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type mockResponse struct {
+	statusCode int
+	header     http.Header
+	body       string
+	err        error
+}
+
+// newSequenceClient returns a mockClient that replies with each response in turn and records the request bodies.
+func newSequenceClient(t *testing.T, responses ...mockResponse) (*mockClient, *[]string) {
+	t.Helper()
+
+	var bodies []string
+	c := &mockClient{}
+	c.doer = func(req *http.Request) (*http.Response, error) {
+		if len(bodies) >= len(responses) {
+			t.Fatalf("unexpected request %d", len(bodies)+1)
+		}
+		var body []byte
+		if req.Body != nil {
+			body, _ = ioutil.ReadAll(req.Body)
+		}
+		r := responses[len(bodies)]
+		bodies = append(bodies, string(body))
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &http.Response{
+			StatusCode: r.statusCode,
+			Header:     r.header,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(r.body))),
+		}, nil
+	}
+	return c, &bodies
+}
+
+func newRetryTestClient(cl *mockClient, policy RetryPolicy) (*Client, *[]time.Duration) {
+	var delays []time.Duration
+	rc := New("apikey", WithRetryPolicy(policy))
+	rc.http = cl
+	rc.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return rc, &delays
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 502, body: "bad gateway"},
+		mockResponse{err: errors.New("connection reset")},
+		mockResponse{statusCode: 200, body: `{"subscriber":{"original_app_user_id":"123"}}`},
+	)
+	rc, delays := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute})
+
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if sub.OriginalAppUserID != "123" {
+		t.Errorf("expected subscriber 123, got: %q", sub.OriginalAppUserID)
+	}
+	if len(*bodies) != 3 {
+		t.Errorf("expected 3 attempts, got: %d", len(*bodies))
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("expected 2 delays, got: %v", *delays)
+	}
+	if d := (*delays)[0]; d < 500*time.Millisecond || d > time.Second {
+		t.Errorf("expected first delay between 500ms and 1s, got: %v", d)
+	}
+	if d := (*delays)[1]; d < time.Second || d > 2*time.Second {
+		t.Errorf("expected second delay between 1s and 2s, got: %v", d)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 500, body: `{"code":7110,"message":"internal"}`},
+		mockResponse{statusCode: 500, body: `{"code":7110,"message":"internal"}`},
+	)
+	rc, _ := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	err := rc.DeleteSubscriber("123")
+	var rcErr Error
+	if !errors.As(err, &rcErr) || rcErr.Code != 7110 {
+		t.Errorf("expected error code 7110, got: %v", err)
+	}
+	if len(*bodies) != 2 {
+		t.Errorf("expected 2 attempts, got: %d", len(*bodies))
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	cl, _ := newSequenceClient(t,
+		mockResponse{statusCode: 429, header: http.Header{"Retry-After": {"3"}}, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+	)
+	rc, delays := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Minute})
+
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 3*time.Second {
+		t.Errorf("expected a single 3s delay, got: %v", *delays)
+	}
+}
+
+func TestRetryAfterExceedsMaxDelay(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 503, header: http.Header{"Retry-After": {"120"}}, body: `{"code":7000,"message":"down"}`},
+	)
+	rc, _ := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute})
+
+	if _, err := rc.GetSubscriber("123"); err == nil {
+		t.Error("expected error")
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("expected 1 attempt, got: %d", len(*bodies))
+	}
+}
+
+func TestRetrySkipsNonIdempotent(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 503, body: `{"code":7000,"message":"down"}`},
+	)
+	rc, _ := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	if _, err := rc.CreatePurchase("123", "receipt", nil); err == nil {
+		t.Error("expected error")
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("expected 1 attempt, got: %d", len(*bodies))
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 503, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+	)
+	rc, _ := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, RetryNonIdempotent: true})
+
+	if _, err := rc.CreatePurchase("123", "receipt", nil); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	expected := `{"app_user_id":"123","fetch_token":"receipt"}`
+	if len(*bodies) != 2 || (*bodies)[0] != expected || (*bodies)[1] != expected {
+		t.Errorf("expected body %q on both attempts, got: %q", expected, *bodies)
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	cl, bodies := newSequenceClient(t,
+		mockResponse{statusCode: 503, body: `{}`},
+	)
+	rc := New("apikey", WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}))
+	rc.http = cl
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := rc.GetSubscriberContext(ctx, "123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("expected 1 attempt, got: %d", len(*bodies))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := staticTime(t, "2020-01-15 23:54:17")
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"10", 10 * time.Second, true},
+		{"-1", 0, false},
+		{"Wed, 15 Jan 2020 23:54:47 GMT", 30 * time.Second, true},
+		{"Wed, 15 Jan 2020 23:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		d, ok := parseRetryAfter(test.value, now)
+		if d != test.expected || ok != test.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, expected %v, %v", test.value, d, ok, test.expected, test.ok)
+		}
+	}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestRetryAfterDateUsesClock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl, _ := newSequenceClient(t,
+		mockResponse{statusCode: 503, header: http.Header{"Retry-After": {now.Add(4 * time.Second).Format(http.TimeFormat)}}, body: `{}`},
+		mockResponse{statusCode: 200, body: `{}`},
+	)
+	rc, delays := newRetryTestClient(cl, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Minute})
+	rc.clock = fixedClock(now)
+
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 4*time.Second {
+		t.Errorf("expected a single 4s delay, got: %v", *delays)
+	}
+}
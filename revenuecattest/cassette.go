@@ -0,0 +1,201 @@
+package revenuecattest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Mode controls whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if it exists and records a new one otherwise.
+	ModeAuto Mode = iota
+	// ModeRecord always makes real requests and overwrites the cassette.
+	ModeRecord
+	// ModeReplay only replays the cassette and fails requests that aren't in it.
+	ModeReplay
+)
+
+// Interaction is a recorded request and response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the parts of a request used for matching.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse holds a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// scrubbedHeaders are never written to a cassette.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Recorder records real request and response pairs to a cassette file and replays them afterwards.
+// It can be passed to revenuecat.WithHTTPClient. Requests match a recording when their method, URL and
+// exact body are equal; identical requests are replayed in the order they were recorded.
+type Recorder struct {
+	path string
+	mode Mode
+	real interface {
+		Do(req *http.Request) (*http.Response, error)
+	}
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	recording    bool
+}
+
+// NewRecorder returns a new *Recorder for the cassette at path. In record mode, real requests are
+// made with client, which defaults to http.DefaultClient.
+func NewRecorder(path string, mode Mode, client interface {
+	Do(req *http.Request) (*http.Response, error)
+}) (*Recorder, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &Recorder{path: path, mode: mode, real: client}
+
+	switch mode {
+	case ModeRecord:
+		r.recording = true
+	case ModeAuto, ModeReplay:
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) && mode == ModeAuto {
+			r.recording = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading cassette: %v", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("error decoding cassette: %v", err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	return r, nil
+}
+
+// Do records or replays req.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %v", err)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: scrub(req.Header),
+		Body:   string(body),
+	}
+
+	if r.recording {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+// Save writes the recorded interactions to the cassette. It does nothing when replaying.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cassette: %v", err)
+	}
+	if err := ioutil.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing cassette: %v", err)
+	}
+	return nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.real.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrub(resp.Header),
+			Body:       string(body),
+		},
+	})
+	r.mu.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || !matches(in.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode: in.Response.StatusCode,
+			Header:     in.Response.Header,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(in.Response.Body))),
+			Request:    req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s with body %q", recorded.Method, recorded.URL, recorded.Body)
+}
+
+func matches(a, b RecordedRequest) bool {
+	return a.Method == b.Method &&
+		a.URL == b.URL &&
+		a.Header.Get("X-Platform") == b.Header.Get("X-Platform") &&
+		a.Header.Get("X-Is-Sandbox") == b.Header.Get("X-Is-Sandbox") &&
+		a.Body == b.Body
+}
+
+func scrub(h http.Header) http.Header {
+	c := h.Clone()
+	for _, k := range scrubbedHeaders {
+		c.Del(k)
+	}
+	if len(c) == 0 {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,97 @@
+package revenuecattest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv := NewServer()
+	rec, err := NewRecorder(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	rc := revenuecat.New("secret_key", revenuecat.WithAPIURL(srv.APIURL()), revenuecat.WithHTTPClient(rec))
+	if _, err := rc.GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	recorded, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	srv.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if strings.Contains(string(data), "secret_key") || strings.Contains(string(data), "Authorization") {
+		t.Errorf("expected Authorization to be scrubbed, got: %s", data)
+	}
+
+	// The server is closed, so these can only be answered from the cassette.
+	rec, err = NewRecorder(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	rc = revenuecat.New("other_key", revenuecat.WithAPIURL(srv.APIURL()), revenuecat.WithHTTPClient(rec))
+	if _, err := rc.GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	replayed, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !replayed.IsEntitledTo("premium") || !replayed.FirstSeen.Equal(recorded.FirstSeen) {
+		t.Errorf("expected recorded subscriber, got: %+v", replayed)
+	}
+
+	// Every interaction has been used up.
+	if _, err := rc.GetSubscriber("123"); err == nil {
+		t.Error("expected error for unrecorded request")
+	}
+}
+
+func TestRecorderReplayMatchesBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv := NewServer()
+	defer srv.Close()
+	rec, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	rc := revenuecat.New("apikey", revenuecat.WithAPIURL(srv.APIURL()), revenuecat.WithHTTPClient(rec))
+	if _, err := rc.GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	rec, err = NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	rc = revenuecat.New("apikey", revenuecat.WithAPIURL(srv.APIURL()), revenuecat.WithHTTPClient(rec))
+	if _, err := rc.GrantEntitlement("123", "premium", revenuecat.Yearly, time.Time{}); err == nil {
+		t.Error("expected error for different request body")
+	}
+}
+
+func TestRecorderReplayMissingCassette(t *testing.T) {
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil)
+	if err == nil {
+		t.Error("expected error for missing cassette")
+	}
+}
@@ -0,0 +1,167 @@
+package revenuecattest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault replaces or alters the response to a request. next serves the request normally.
+type Fault func(w http.ResponseWriter, r *http.Request, next http.Handler)
+
+// Rule selects the requests a Fault applies to.
+type Rule struct {
+	// Method matches the HTTP method. Empty matches any method.
+	Method string
+	// Path matches the request path after /v1/ using path.Match syntax,
+	// for example "subscribers/*/entitlements/*/promotional". Empty matches any path.
+	Path string
+	// UserID matches the app user ID in the path, or in the body of a receipts request. Empty matches any user.
+	UserID string
+	// Nth applies the fault only to the Nth matching request, counting from 1. Zero applies it to every matching request.
+	Nth int
+	// Times limits how many times the fault is applied. Zero means no limit.
+	Times int
+}
+
+type injectedFault struct {
+	rule    Rule
+	fault   Fault
+	matched int
+	applied int
+}
+
+// Inject applies fault to the requests selected by rule. When several faults match a request,
+// the first one injected wins.
+func (s *Server) Inject(rule Rule, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &injectedFault{rule: rule, fault: fault})
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// fault returns the fault to apply to a request, updating the match counters of every matching rule.
+// s.mu must be held.
+func (s *Server) fault(method, apiPath, userID string) Fault {
+	var found Fault
+	for _, f := range s.faults {
+		if !f.rule.matches(method, apiPath, userID) {
+			continue
+		}
+		f.matched++
+		if found != nil || (f.rule.Nth > 0 && f.matched != f.rule.Nth) || (f.rule.Times > 0 && f.applied >= f.rule.Times) {
+			continue
+		}
+		f.applied++
+		found = f.fault
+	}
+	return found
+}
+
+func (r Rule) matches(method, apiPath, userID string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	if r.Path != "" {
+		if ok, _ := path.Match(r.Path, apiPath); !ok {
+			return false
+		}
+	}
+	return r.UserID == "" || r.UserID == userID
+}
+
+// requestUserID returns the app user ID a request is for. The body of a receipts request is read and restored.
+func requestUserID(r *http.Request, parts []string) string {
+	if len(parts) >= 2 && parts[0] == "subscribers" {
+		return parts[1]
+	}
+	if len(parts) == 1 && parts[0] == "receipts" && r.Body != nil {
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		var req struct {
+			AppUserID string `json:"app_user_id"`
+		}
+		json.Unmarshal(body, &req)
+		return req.AppUserID
+	}
+	return ""
+}
+
+// RateLimited responds with a 429 and the given Retry-After, rounded up to whole seconds.
+func RateLimited(retryAfter time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		secs := int((retryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		writeError(w, http.StatusTooManyRequests, 0, "Too many requests.")
+	}
+}
+
+// StatusError responds with a RevenueCat JSON error.
+func StatusError(status, code int, message string) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		writeError(w, status, code, message)
+	}
+}
+
+// HTMLError responds with a non-JSON error page, like a proxy or load balancer in front of the API would.
+func HTMLError(status int) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		text := strconv.Itoa(status) + " " + http.StatusText(status)
+		w.Write([]byte("<html><head><title>" + text + "</title></head><body><h1>" + text + "</h1></body></html>"))
+	}
+}
+
+// Latency delays the request by d before serving it normally.
+func Latency(d time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			next.ServeHTTP(w, r)
+		case <-r.Context().Done():
+		}
+	}
+}
+
+// Stall holds the request without responding for d, or until the client gives up,
+// then drops the connection.
+func Stall(d time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// TruncatedJSON serves the request normally but cuts the response body in half.
+func TruncatedJSON() Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		body := rec.Body.Bytes()
+		w.WriteHeader(rec.Code)
+		w.Write(body[:len(body)/2])
+	}
+}
@@ -0,0 +1,117 @@
+package revenuecattest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestInjectRateLimitedNth(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{Method: "GET", Path: "subscribers/*", Nth: 2}, RateLimited(time.Second))
+	rc := srv.Client()
+
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Errorf("first call: %v", err)
+	}
+	_, err := rc.GetSubscriber("123")
+	var rcErr revenuecat.Error
+	if !errors.As(err, &rcErr) || rcErr.StatusCode != http.StatusTooManyRequests || rcErr.Response.Header.Get("Retry-After") != "1" {
+		t.Errorf("second call: expected 429 with Retry-After, got: %v", err)
+	}
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Errorf("third call: %v", err)
+	}
+}
+
+func TestInjectPerUserAndPath(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{Path: "subscribers/*/entitlements/*/promotional", UserID: "bad"}, StatusError(http.StatusServiceUnavailable, 0, "Unavailable."))
+	srv.Inject(Rule{Path: "receipts", UserID: "bad"}, StatusError(http.StatusBadRequest, revenuecat.ErrorCodeInvalidReceiptToken, "Invalid."))
+	rc := srv.Client()
+
+	if _, err := rc.GrantEntitlement("good", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Errorf("expected other users to be unaffected, got: %v", err)
+	}
+	if _, err := rc.GetSubscriber("bad"); err != nil {
+		t.Errorf("expected other paths to be unaffected, got: %v", err)
+	}
+	if _, err := rc.GrantEntitlement("bad", "premium", revenuecat.Monthly, time.Time{}); !revenuecat.IsRetryable(err) {
+		t.Errorf("expected 503, got: %v", err)
+	}
+	opt := &revenuecat.CreatePurchaseOptions{ProductID: "monthly"}
+	if _, err := rc.CreatePurchase("bad", "token", opt); !revenuecat.IsInvalidReceipt(err) {
+		t.Errorf("expected invalid receipt, got: %v", err)
+	}
+	if _, err := rc.CreatePurchase("good", "token", opt); err != nil {
+		t.Errorf("expected receipts for other users to be unaffected, got: %v", err)
+	}
+}
+
+func TestInjectTimes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{Times: 2}, StatusError(http.StatusInternalServerError, revenuecat.ErrorCodeInternalServerError, "Internal."))
+	rc := srv.Client(revenuecat.WithRetryPolicy(revenuecat.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	if _, err := rc.GetSubscriber("123"); err != nil {
+		t.Errorf("expected third attempt to succeed, got: %v", err)
+	}
+}
+
+func TestInjectHTMLError(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{}, HTMLError(http.StatusBadGateway))
+
+	_, err := srv.Client().GetSubscriber("123")
+	var rcErr revenuecat.Error
+	if !errors.As(err, &rcErr) || rcErr.StatusCode != http.StatusBadGateway || !strings.Contains(string(rcErr.Response.Body), "<html>") {
+		t.Errorf("expected 502 with an HTML body, got: %v", err)
+	}
+}
+
+func TestInjectTruncatedJSON(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{}, TruncatedJSON())
+
+	_, err := srv.Client().GetSubscriber("123")
+	if err == nil || !strings.Contains(err.Error(), "error decoding response") {
+		t.Errorf("expected decode error, got: %v", err)
+	}
+}
+
+func TestInjectStall(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{}, Stall(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := srv.Client().GetSubscriberContext(ctx, "123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Rule{}, Latency(50*time.Millisecond))
+
+	start := time.Now()
+	if _, err := srv.Client().GetSubscriber("123"); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected at least 50ms latency, got: %v", d)
+	}
+}
@@ -0,0 +1,202 @@
+package revenuecattest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/webhook"
+)
+
+// Clock is a virtual clock that only moves when told to.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a new *Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SetClock makes the server use clock instead of the system time. Store lifecycle events, such as renewals
+// and trial conversions, are applied whenever the server is next used after the clock moves.
+func (s *Server) SetClock(clock *Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = clock.Now
+}
+
+// Cancel turns off auto-renew for a subscription, like a user cancelling in the store.
+// The subscription stays active until it expires.
+func (s *Server) Cancel(userID, productID string) error {
+	return s.updateSubscription(userID, productID, webhook.Cancellation, func(sub *revenuecat.Subscription, now time.Time) {
+		if sub.UnsubscribeDetectedAt == nil {
+			sub.UnsubscribeDetectedAt = &now
+		}
+	})
+}
+
+// Uncancel turns auto-renew back on for a cancelled subscription that hasn't expired.
+func (s *Server) Uncancel(userID, productID string) error {
+	return s.updateSubscription(userID, productID, webhook.Uncancellation, func(sub *revenuecat.Subscription, now time.Time) {
+		sub.UnsubscribeDetectedAt = nil
+	})
+}
+
+// Renew charges the subscription for another period straight away, extending it from its current expiry.
+// It resolves any billing issue.
+func (s *Server) Renew(userID, productID string) error {
+	return s.updateSubscription(userID, productID, webhook.Renewal, func(sub *revenuecat.Subscription, now time.Time) {
+		start := now
+		if sub.ExpiresDate != nil && sub.ExpiresDate.After(now) {
+			start = *sub.ExpiresDate
+		}
+		s.renew(sub, productID, start)
+	})
+}
+
+// BillingIssue records a failed charge. The subscription won't renew until Renew is called, and the store
+// grants a grace period of gracePeriod past the current expiry, if it is non-zero.
+func (s *Server) BillingIssue(userID, productID string, gracePeriod time.Duration) error {
+	return s.updateSubscription(userID, productID, webhook.BillingIssue, func(sub *revenuecat.Subscription, now time.Time) {
+		sub.BillingIssuesDetectedAt = &now
+		sub.GracePeriodExpiresDate = nil
+		if gracePeriod > 0 && sub.ExpiresDate != nil {
+			grace := sub.ExpiresDate.Add(gracePeriod)
+			sub.GracePeriodExpiresDate = &grace
+		}
+	})
+}
+
+// Pause pauses a Google Play subscription when it next expires, resuming it automatically at resumeAt.
+func (s *Server) Pause(userID, productID string, resumeAt time.Time) error {
+	return s.updateSubscription(userID, productID, webhook.SubscriptionPaused, func(sub *revenuecat.Subscription, now time.Time) {
+		sub.AutoResumeDate = &resumeAt
+	})
+}
+
+// Expire ends a subscription immediately.
+func (s *Server) Expire(userID, productID string) error {
+	return s.updateSubscription(userID, productID, "", func(sub *revenuecat.Subscription, now time.Time) {
+		sub.ExpiresDate = &now
+		sub.GracePeriodExpiresDate = nil
+		if sub.UnsubscribeDetectedAt == nil {
+			sub.UnsubscribeDetectedAt = &now
+		}
+	})
+}
+
+// Refund refunds the latest purchase of a subscription and revokes access immediately.
+func (s *Server) Refund(userID, productID string) error {
+	return s.updateSubscription(userID, productID, webhook.Cancellation, func(sub *revenuecat.Subscription, now time.Time) {
+		sub.RefundedAt = &now
+		sub.ExpiresDate = &now
+		sub.GracePeriodExpiresDate = nil
+		if sub.UnsubscribeDetectedAt == nil {
+			sub.UnsubscribeDetectedAt = &now
+		}
+	})
+}
+
+// updateSubscription applies fn to a subscription and emits a webhook of type typ, if it isn't empty.
+func (s *Server) updateSubscription(userID, productID string, typ webhook.EventType, fn func(sub *revenuecat.Subscription, now time.Time)) error {
+	s.mu.Lock()
+	defer s.unlock()
+	s.tick()
+
+	state, ok := s.subscribers[userID]
+	if !ok {
+		return fmt.Errorf("subscriber %q not found", userID)
+	}
+	sub, ok := state.sub.Subscriptions[productID]
+	if !ok {
+		return fmt.Errorf("subscription %q not found for subscriber %q", productID, userID)
+	}
+	fn(&sub, s.now())
+	state.sub.Subscriptions[productID] = sub
+	state.updateEntitlements()
+	if typ != "" {
+		s.emit(typ, state, productID, nil)
+	}
+	return nil
+}
+
+// renew starts a new normal period for a subscription at start. s.mu must be held.
+func (s *Server) renew(sub *revenuecat.Subscription, productID string, start time.Time) {
+	expires := start.Add(s.products[productID].duration())
+	s.txnID++
+	sub.PurchaseDate = start
+	sub.ExpiresDate = &expires
+	sub.PeriodType = revenuecat.NormalPeriodType
+	sub.StoreTransactionID = fmt.Sprintf("txn_%d", s.txnID)
+	sub.BillingIssuesDetectedAt = nil
+	sub.GracePeriodExpiresDate = nil
+	sub.AutoResumeDate = nil
+}
+
+// tick applies the store lifecycle up to the current time: subscriptions that reached their expiry
+// renew, converting trials to normal periods, unless they were cancelled, have a billing issue or were
+// refunded. Paused subscriptions resume at their auto-resume date. s.mu must be held.
+func (s *Server) tick() {
+	now := s.now()
+	for _, state := range s.subscribers {
+		changed := false
+		for productID, sub := range state.sub.Subscriptions {
+			if sub.Store == revenuecat.PromotionalStore || sub.ExpiresDate == nil {
+				continue
+			}
+			for !now.Before(*sub.ExpiresDate) {
+				if sub.AutoResumeDate != nil {
+					if now.Before(*sub.AutoResumeDate) {
+						break
+					}
+					s.renew(&sub, productID, *sub.AutoResumeDate)
+					state.sub.Subscriptions[productID] = sub
+					s.emit(webhook.Renewal, state, productID, func(e *webhook.Event) {
+						e.EventTimestamp = sub.PurchaseDate
+					})
+					changed = true
+					continue
+				}
+				if sub.UnsubscribeDetectedAt != nil || sub.BillingIssuesDetectedAt != nil || sub.RefundedAt != nil {
+					break
+				}
+				trial := sub.PeriodType == revenuecat.TrialPeriodType
+				s.renew(&sub, productID, *sub.ExpiresDate)
+				state.sub.Subscriptions[productID] = sub
+				s.emit(webhook.Renewal, state, productID, func(e *webhook.Event) {
+					e.EventTimestamp = sub.PurchaseDate
+					e.IsTrialConversion = trial
+				})
+				changed = true
+			}
+		}
+		if changed {
+			state.updateEntitlements()
+		}
+		s.emitExpirations(state)
+	}
+}
@@ -0,0 +1,172 @@
+package revenuecattest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+const day = 24 * time.Hour
+
+func newLifecycleServer(t *testing.T) (*Server, *Clock, *revenuecat.Client) {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	clock := NewClock(time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC))
+	srv.SetClock(clock)
+	srv.AddProduct("monthly", Product{Entitlements: []string{"premium"}, Duration: 30 * day, TrialDuration: 7 * day})
+	rc := srv.Client()
+	if _, err := rc.CreatePurchase("123", "token", &revenuecat.CreatePurchaseOptions{ProductID: "monthly"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	return srv, clock, rc
+}
+
+func subscription(t *testing.T, srv *Server) revenuecat.Subscription {
+	t.Helper()
+	sub, ok := srv.Subscriber("123")
+	if !ok {
+		t.Fatal("subscriber not found")
+	}
+	return sub.Subscriptions["monthly"]
+}
+
+func TestLifecycleTrialConvertsAndRenews(t *testing.T) {
+	srv, clock, _ := newLifecycleServer(t)
+	start := clock.Now()
+
+	if s := subscription(t, srv); s.PeriodType != revenuecat.TrialPeriodType || !s.ExpiresDate.Equal(start.Add(7*day)) {
+		t.Errorf("expected 7 day trial, got: %+v", s)
+	}
+
+	clock.Advance(7 * day)
+	s := subscription(t, srv)
+	if s.PeriodType != revenuecat.NormalPeriodType || !s.ExpiresDate.Equal(start.Add(37*day)) {
+		t.Errorf("expected trial to convert to a normal period, got: %+v", s)
+	}
+
+	clock.Advance(61 * day)
+	s = subscription(t, srv)
+	if !s.ExpiresDate.Equal(start.Add(97 * day)) {
+		t.Errorf("expected two more renewals, got expiry: %v", s.ExpiresDate)
+	}
+	if sub, _ := srv.Subscriber("123"); !sub.Entitlements["premium"].ExpiresDate.Equal(*s.ExpiresDate) {
+		t.Errorf("expected entitlement to follow the subscription, got: %+v", sub.Entitlements["premium"])
+	}
+}
+
+func TestLifecycleCancellation(t *testing.T) {
+	srv, clock, rc := newLifecycleServer(t)
+
+	clock.Advance(day)
+	if err := srv.Cancel("123", "monthly"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if s := subscription(t, srv); s.UnsubscribeDetectedAt == nil || !s.UnsubscribeDetectedAt.Equal(clock.Now()) {
+		t.Errorf("expected unsubscribe_detected_at to be set, got: %+v", s)
+	}
+
+	clock.Advance(7 * day)
+	s := subscription(t, srv)
+	if !s.ExpiresDate.Before(clock.Now()) {
+		t.Errorf("expected cancelled subscription not to renew, got: %+v", s)
+	}
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if ent := sub.Entitlements["premium"]; ent.ExpiresDate.After(clock.Now()) {
+		t.Errorf("expected entitlement to have expired, got: %+v", ent)
+	}
+}
+
+func TestLifecycleBillingIssue(t *testing.T) {
+	srv, clock, _ := newLifecycleServer(t)
+
+	srv.BillingIssue("123", "monthly", 3*day)
+	s := subscription(t, srv)
+	if s.BillingIssuesDetectedAt == nil || !s.GracePeriodExpiresDate.Equal(s.ExpiresDate.Add(3*day)) {
+		t.Errorf("expected billing issue with grace period, got: %+v", s)
+	}
+
+	clock.Advance(8 * day)
+	s = subscription(t, srv)
+	if s.ExpiresDate.After(clock.Now()) {
+		t.Errorf("expected subscription not to renew during a billing issue, got: %+v", s)
+	}
+	sub, _ := srv.Subscriber("123")
+	if ent := sub.Entitlements["premium"]; ent.GracePeriodExpiresDate == nil || !ent.GracePeriodExpiresDate.After(clock.Now()) {
+		t.Errorf("expected entitlement in its grace period, got: %+v", ent)
+	}
+
+	srv.Renew("123", "monthly")
+	s = subscription(t, srv)
+	if s.BillingIssuesDetectedAt != nil || s.GracePeriodExpiresDate != nil || !s.ExpiresDate.Equal(clock.Now().Add(30*day)) {
+		t.Errorf("expected renewal to resolve the billing issue, got: %+v", s)
+	}
+}
+
+func TestLifecyclePause(t *testing.T) {
+	srv, clock, _ := newLifecycleServer(t)
+	start := clock.Now()
+
+	srv.Pause("123", "monthly", start.Add(20*day))
+	clock.Advance(10 * day)
+	if s := subscription(t, srv); !s.ExpiresDate.Equal(start.Add(7 * day)) {
+		t.Errorf("expected subscription to be paused at expiry, got: %+v", s)
+	}
+
+	clock.Advance(10 * day)
+	s := subscription(t, srv)
+	if s.AutoResumeDate != nil || !s.ExpiresDate.Equal(start.Add(50*day)) {
+		t.Errorf("expected subscription to resume, got: %+v", s)
+	}
+}
+
+func TestLifecycleExpireAndRefund(t *testing.T) {
+	srv, clock, _ := newLifecycleServer(t)
+
+	srv.Refund("123", "monthly")
+	s := subscription(t, srv)
+	if s.RefundedAt == nil || !s.ExpiresDate.Equal(clock.Now()) {
+		t.Errorf("expected refund to end the subscription, got: %+v", s)
+	}
+
+	if err := srv.Expire("123", "unknown"); err == nil {
+		t.Error("expected error for unknown subscription")
+	}
+	if err := srv.Expire("456", "monthly"); err == nil {
+		t.Error("expected error for unknown subscriber")
+	}
+}
+
+func TestLifecycleNoSecondTrial(t *testing.T) {
+	srv, clock, rc := newLifecycleServer(t)
+
+	srv.Expire("123", "monthly")
+	clock.Advance(day)
+	if _, err := rc.CreatePurchase("123", "token2", &revenuecat.CreatePurchaseOptions{ProductID: "monthly"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if s := subscription(t, srv); s.PeriodType != revenuecat.NormalPeriodType || !s.ExpiresDate.Equal(clock.Now().Add(30*day)) {
+		t.Errorf("expected a normal period without trial, got: %+v", s)
+	}
+}
+
+func TestLifecycleClientClock(t *testing.T) {
+	srv, clock, _ := newLifecycleServer(t)
+	rc := srv.Client(revenuecat.WithClock(clock))
+
+	clock.Advance(8 * day)
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !sub.RequestDate.Equal(clock.Now()) {
+		t.Errorf("expected request date %v, got %v", clock.Now(), sub.RequestDate)
+	}
+	if !sub.IsEntitledToAt("premium", rc.Now()) {
+		t.Error("expected converted trial to be active")
+	}
+}
@@ -0,0 +1,588 @@
+// Package revenuecattest provides an in-memory fake of the RevenueCat v1 API for tests.
+//
+// The fake keeps per-subscriber state, so a GrantEntitlement followed by a GetSubscriber returns the grant:
+//
+//	srv := revenuecattest.NewServer()
+//	defer srv.Close()
+//	rc := revenuecat.New("apikey", revenuecat.WithAPIURL(srv.APIURL()))
+package revenuecattest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/webhook"
+)
+
+// Product configures what a purchase of a product ID unlocks.
+type Product struct {
+	// Entitlements holds the entitlement identifiers the product unlocks.
+	Entitlements []string
+	// Duration is the length of one subscription period. Defaults to 30 days.
+	Duration time.Duration
+	// TrialDuration is the length of the free trial offered on a subscriber's first purchase of the product.
+	TrialDuration time.Duration
+	// NonRenewing makes purchases of the product non-subscriptions that never expire.
+	NonRenewing bool
+}
+
+// Attribution holds attribution data sent with AddUserAttribution.
+type Attribution struct {
+	Network revenuecat.Network         `json:"network"`
+	Data    revenuecat.AttributionData `json:"data"`
+}
+
+// Server is a fake RevenueCat API server.
+type Server struct {
+	srv *httptest.Server
+
+	mu          sync.Mutex
+	now         func() time.Time
+	products    map[string]Product
+	subscribers map[string]*subscriber
+	txnID       int
+	faults      []*injectedFault
+
+	webhookURL           string
+	webhookAuthorization string
+	webhookErrs          []error
+	pending              []webhook.Event
+	eventID              int
+}
+
+type subscriber struct {
+	sub revenuecat.Subscriber
+	// entitlements holds the entitlement identifiers unlocked by each product the subscriber owns.
+	entitlements     map[string][]string
+	offeringOverride string
+	attributions     []Attribution
+	// expired holds the products an EXPIRATION webhook has been emitted for since they were last active.
+	expired map[string]bool
+}
+
+// NewServer starts and returns a new fake *Server. Callers should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		now:         time.Now,
+		products:    make(map[string]Product),
+		subscribers: make(map[string]*subscriber),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// APIURL returns the base URL to pass to revenuecat.WithAPIURL.
+func (s *Server) APIURL() string {
+	return s.srv.URL + "/v1/"
+}
+
+// Client returns a *revenuecat.Client that talks to the fake server.
+func (s *Server) Client(opts ...revenuecat.Option) *revenuecat.Client {
+	return revenuecat.New("test_api_key", append([]revenuecat.Option{revenuecat.WithAPIURL(s.APIURL())}, opts...)...)
+}
+
+// Close shuts down the server, dropping any stalled requests.
+func (s *Server) Close() {
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+}
+
+// AddProduct configures the product with the given ID. Purchases of unknown products are treated as
+// 30 day subscriptions without entitlements.
+func (s *Server) AddProduct(id string, p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products[id] = p
+}
+
+// Subscriber returns a copy of the stored subscriber, without creating it.
+func (s *Server) Subscriber(userID string) (revenuecat.Subscriber, bool) {
+	s.mu.Lock()
+	defer s.unlock()
+	s.tick()
+	sub, ok := s.subscribers[userID]
+	if !ok {
+		return revenuecat.Subscriber{}, false
+	}
+	return sub.sub.Clone(), true
+}
+
+// OfferingOverride returns the offering UUID a subscriber is overridden to, if any.
+func (s *Server) OfferingOverride(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subscribers[userID]; ok {
+		return sub.offeringOverride
+	}
+	return ""
+}
+
+// Attributions returns the attribution data added for a subscriber.
+func (s *Server) Attributions(userID string) []Attribution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subscribers[userID]; ok {
+		return append([]Attribution(nil), sub.attributions...)
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, revenuecat.ErrorCodeInvalidAPIKey, "Invalid API key.")
+		return
+	}
+	apiPath := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if apiPath == r.URL.Path {
+		writeError(w, http.StatusNotFound, 0, "Not found.")
+		return
+	}
+	parts := strings.Split(apiPath, "/")
+	userID := requestUserID(r, parts)
+
+	s.mu.Lock()
+	fault := s.fault(r.Method, apiPath, userID)
+	s.mu.Unlock()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Record the response so webhooks are delivered before the client sees it.
+		rec := httptest.NewRecorder()
+		s.mu.Lock()
+		s.tick()
+		s.route(rec, r, parts)
+		s.unlock()
+
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	})
+	if fault != nil {
+		fault(w, r, next)
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case r.Method == "POST" && len(parts) == 1 && parts[0] == "receipts":
+		s.createPurchase(w, r)
+	case len(parts) >= 2 && parts[0] == "subscribers":
+		s.routeSubscriber(w, r, parts[1], parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, 0, "Not found.")
+	}
+}
+
+func (s *Server) routeSubscriber(w http.ResponseWriter, r *http.Request, userID string, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		s.getSubscriber(w, r, userID)
+	case len(parts) == 0 && r.Method == "DELETE":
+		s.deleteSubscriber(w, userID)
+	case len(parts) == 1 && parts[0] == "attributes" && r.Method == "POST":
+		s.updateAttributes(w, r, userID)
+	case len(parts) == 1 && parts[0] == "attribution" && r.Method == "POST":
+		s.addAttribution(w, r, userID)
+	case len(parts) == 3 && parts[0] == "entitlements" && parts[2] == "promotional" && r.Method == "POST":
+		s.grantEntitlement(w, r, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "entitlements" && parts[2] == "revoke_promotionals" && r.Method == "POST":
+		s.revokeEntitlement(w, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "offerings" && parts[2] == "override" && r.Method == "POST":
+		s.overrideOffering(w, userID, parts[1])
+	case len(parts) == 2 && parts[0] == "offerings" && parts[1] == "override" && r.Method == "DELETE":
+		s.deleteOfferingOverride(w, userID)
+	case len(parts) == 3 && parts[0] == "subscriptions" && parts[2] == "revoke" && r.Method == "POST":
+		s.refundGoogleSubscription(w, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "subscriptions" && parts[2] == "defer" && r.Method == "POST":
+		s.deferGoogleSubscription(w, r, userID, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, 0, "Not found.")
+	}
+}
+
+func (s *Server) getSubscriber(w http.ResponseWriter, r *http.Request, userID string) {
+	sub := s.subscriber(userID)
+	sub.sub.LastSeen = s.now()
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) deleteSubscriber(w http.ResponseWriter, userID string) {
+	if _, ok := s.subscribers[userID]; !ok {
+		writeError(w, http.StatusNotFound, 0, "Subscriber not found.")
+		return
+	}
+	delete(s.subscribers, userID)
+	writeJSON(w, http.StatusOK, map[string]string{"app_user_id": userID})
+}
+
+func (s *Server) updateAttributes(w http.ResponseWriter, r *http.Request, userID string) {
+	var req struct {
+		Attributes map[string]revenuecat.SubscriberAttribute `json:"attributes"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	sub := s.subscriber(userID)
+	for k, v := range req.Attributes {
+		if v.Value == "" {
+			delete(sub.sub.SubscriberAttributes, k)
+			continue
+		}
+		if v.UpdatedAt.IsZero() {
+			v.UpdatedAt = s.now()
+		}
+		sub.sub.SubscriberAttributes[k] = v
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) addAttribution(w http.ResponseWriter, r *http.Request, userID string) {
+	var req Attribution
+	if !decode(w, r, &req) {
+		return
+	}
+	sub := s.subscriber(userID)
+	sub.attributions = append(sub.attributions, req)
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) createPurchase(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AppUserID  string                                    `json:"app_user_id"`
+		FetchToken string                                    `json:"fetch_token"`
+		ProductID  string                                    `json:"product_id"`
+		Attributes map[string]revenuecat.SubscriberAttribute `json:"attributes"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.AppUserID == "" {
+		writeError(w, http.StatusBadRequest, revenuecat.ErrorCodeEmptyAppUserID, "app_user_id is required.")
+		return
+	}
+	if req.FetchToken == "" || req.ProductID == "" {
+		writeError(w, http.StatusBadRequest, revenuecat.ErrorCodeInvalidReceiptToken, "The receipt is not valid.")
+		return
+	}
+
+	sub := s.subscriber(req.AppUserID)
+	for k, v := range req.Attributes {
+		sub.sub.SubscriberAttributes[k] = v
+	}
+	product, ok := s.products[req.ProductID]
+	if !ok {
+		product = Product{}
+	}
+	store := platformStore(r.Header.Get("X-Platform"))
+	sandbox := r.Header.Get("X-Is-Sandbox") == "true"
+	now := s.now()
+	sub.entitlements[req.ProductID] = product.Entitlements
+
+	if product.NonRenewing {
+		s.txnID++
+		purchase := revenuecat.NonSubscription{
+			ID:           fmt.Sprintf("txn_%d", s.txnID),
+			PurchaseDate: now,
+			Store:        store,
+			IsSandbox:    sandbox,
+		}
+		sub.sub.NonSubscriptions[req.ProductID] = append(sub.sub.NonSubscriptions[req.ProductID], purchase)
+		s.emit(webhook.NonRenewingPurchase, sub, req.ProductID, func(e *webhook.Event) {
+			e.PurchasedAt = now
+			e.Store = store
+			e.TransactionID = purchase.ID
+			if sandbox {
+				e.Environment = webhook.Sandbox
+			}
+		})
+	} else if existing, ok := sub.sub.Subscriptions[req.ProductID]; !ok || (existing.ExpiresDate != nil && !existing.ExpiresDate.After(now)) {
+		s.txnID++
+		subscription := revenuecat.Subscription{
+			PurchaseDate:         now,
+			OriginalPurchaseDate: now,
+			PeriodType:           revenuecat.NormalPeriodType,
+			Store:                store,
+			IsSandbox:            sandbox,
+			OwnershipType:        revenuecat.PurchasedOwnershipType,
+			StoreTransactionID:   fmt.Sprintf("txn_%d", s.txnID),
+		}
+		period := product.duration()
+		// Trials are only offered to subscribers who never had the product.
+		if !ok && product.TrialDuration > 0 {
+			period = product.TrialDuration
+			subscription.PeriodType = revenuecat.TrialPeriodType
+		}
+		if ok {
+			subscription.OriginalPurchaseDate = existing.OriginalPurchaseDate
+		}
+		expires := now.Add(period)
+		subscription.ExpiresDate = &expires
+		sub.sub.Subscriptions[req.ProductID] = subscription
+		delete(sub.expired, req.ProductID)
+		// Resubscribing after an expiration is reported as a renewal.
+		typ := webhook.InitialPurchase
+		if ok {
+			typ = webhook.Renewal
+		}
+		s.emit(typ, sub, req.ProductID, nil)
+	}
+	sub.updateEntitlements()
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) grantEntitlement(w http.ResponseWriter, r *http.Request, userID string, entitlementID string) {
+	var req struct {
+		Duration    revenuecat.Duration `json:"duration"`
+		StartTimeMs int64               `json:"start_time_ms"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	start := s.now()
+	if req.StartTimeMs > 0 {
+		start = revenuecat.FromMilliseconds(req.StartTimeMs)
+	}
+	expires, ok := durationEnd(start, req.Duration)
+	if !ok {
+		writeError(w, http.StatusBadRequest, revenuecat.ErrorCodeBadRequest, "Invalid duration.")
+		return
+	}
+
+	sub := s.subscriber(userID)
+	productID := "rc_promo_" + entitlementID + "_" + string(req.Duration)
+	sub.sub.Subscriptions[productID] = revenuecat.Subscription{
+		ExpiresDate:          expires,
+		PurchaseDate:         start,
+		OriginalPurchaseDate: start,
+		PeriodType:           revenuecat.NormalPeriodType,
+		Store:                revenuecat.PromotionalStore,
+		OwnershipType:        revenuecat.PurchasedOwnershipType,
+	}
+	sub.entitlements[productID] = []string{entitlementID}
+	sub.updateEntitlements()
+	s.emit(webhook.NonRenewingPurchase, sub, productID, nil)
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) revokeEntitlement(w http.ResponseWriter, userID string, entitlementID string) {
+	sub := s.subscriber(userID)
+	for productID, subscription := range sub.sub.Subscriptions {
+		if subscription.Store == revenuecat.PromotionalStore && contains(sub.entitlements[productID], entitlementID) {
+			now := s.now()
+			subscription.ExpiresDate = &now
+			sub.sub.Subscriptions[productID] = subscription
+			s.emit(webhook.Expiration, sub, productID, func(e *webhook.Event) {
+				e.ExpirationReason = webhook.DeveloperInitiated
+			})
+			delete(sub.sub.Subscriptions, productID)
+			delete(sub.entitlements, productID)
+		}
+	}
+	sub.updateEntitlements()
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) overrideOffering(w http.ResponseWriter, userID string, offeringUUID string) {
+	sub := s.subscriber(userID)
+	sub.offeringOverride = offeringUUID
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) deleteOfferingOverride(w http.ResponseWriter, userID string) {
+	sub := s.subscriber(userID)
+	sub.offeringOverride = ""
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) refundGoogleSubscription(w http.ResponseWriter, userID string, productID string) {
+	sub := s.subscriber(userID)
+	subscription, ok := sub.sub.Subscriptions[productID]
+	if !ok || subscription.Store != revenuecat.PlayStore {
+		writeError(w, http.StatusNotFound, 0, "Google subscription not found.")
+		return
+	}
+	now := s.now()
+	subscription.RefundedAt = &now
+	subscription.UnsubscribeDetectedAt = &now
+	subscription.ExpiresDate = &now
+	sub.sub.Subscriptions[productID] = subscription
+	sub.updateEntitlements()
+	s.emit(webhook.Cancellation, sub, productID, nil)
+	s.writeSubscriber(w, sub)
+}
+
+func (s *Server) deferGoogleSubscription(w http.ResponseWriter, r *http.Request, userID string, productID string) {
+	var req struct {
+		ExpiryTimeMs int64 `json:"expiry_time_ms"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	sub := s.subscriber(userID)
+	subscription, ok := sub.sub.Subscriptions[productID]
+	if !ok || subscription.Store != revenuecat.PlayStore {
+		writeError(w, http.StatusNotFound, 0, "Google subscription not found.")
+		return
+	}
+	expires := revenuecat.FromMilliseconds(req.ExpiryTimeMs)
+	if subscription.ExpiresDate != nil && !expires.After(*subscription.ExpiresDate) {
+		writeError(w, http.StatusBadRequest, revenuecat.ErrorCodeBadRequest, "expiry_time_ms must be after the current expiry.")
+		return
+	}
+	subscription.ExpiresDate = &expires
+	sub.sub.Subscriptions[productID] = subscription
+	sub.updateEntitlements()
+	s.emit(webhook.SubscriptionExtended, sub, productID, nil)
+	s.writeSubscriber(w, sub)
+}
+
+// subscriber returns the state for userID, creating it like the real API does.
+func (s *Server) subscriber(userID string) *subscriber {
+	sub, ok := s.subscribers[userID]
+	if ok {
+		return sub
+	}
+	now := s.now()
+	sub = &subscriber{
+		sub: revenuecat.Subscriber{
+			OriginalAppUserID:    userID,
+			FirstSeen:            now,
+			LastSeen:             now,
+			Entitlements:         make(map[string]revenuecat.Entitlement),
+			Subscriptions:        make(map[string]revenuecat.Subscription),
+			NonSubscriptions:     make(map[string][]revenuecat.NonSubscription),
+			SubscriberAttributes: make(map[string]revenuecat.SubscriberAttribute),
+		},
+		entitlements: make(map[string][]string),
+		expired:      make(map[string]bool),
+	}
+	s.subscribers[userID] = sub
+	return sub
+}
+
+// updateEntitlements rebuilds the subscriber's entitlements from the products it owns. Each entitlement is
+// backed by the product that expires last, and expired entitlements are kept, as in the real API.
+func (sub *subscriber) updateEntitlements() {
+	ents := make(map[string]revenuecat.Entitlement)
+	set := func(productID string, ent revenuecat.Entitlement) {
+		for _, id := range sub.entitlements[productID] {
+			if cur, ok := ents[id]; ok && cur.ExpiresAfter(ent.ExpiresDate) {
+				continue
+			}
+			ents[id] = ent
+		}
+	}
+	for productID, subscription := range sub.sub.Subscriptions {
+		set(productID, revenuecat.Entitlement{
+			ExpiresDate:            subscription.ExpiresDate,
+			PurchaseDate:           subscription.PurchaseDate,
+			ProductIdentifier:      productID,
+			GracePeriodExpiresDate: subscription.GracePeriodExpiresDate,
+		})
+	}
+	for productID, purchases := range sub.sub.NonSubscriptions {
+		for _, p := range purchases {
+			// Non-subscription purchases unlock their entitlements for life.
+			set(productID, revenuecat.Entitlement{
+				PurchaseDate:      p.PurchaseDate,
+				ProductIdentifier: productID,
+			})
+		}
+	}
+	sub.sub.Entitlements = ents
+}
+
+func (s *Server) writeSubscriber(w http.ResponseWriter, sub *subscriber) {
+	now := s.now()
+	writeJSON(w, http.StatusOK, struct {
+		RequestDate   time.Time             `json:"request_date"`
+		RequestDateMs int64                 `json:"request_date_ms"`
+		Subscriber    revenuecat.Subscriber `json:"subscriber"`
+	}{
+		RequestDate:   now.UTC(),
+		RequestDateMs: revenuecat.ToMilliseconds(now),
+		Subscriber:    sub.sub,
+	})
+}
+
+func (p Product) duration() time.Duration {
+	if p.Duration <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return p.Duration
+}
+
+// durationEnd returns when a promotional grant of duration d starting at start ends, or nil for lifetime grants.
+func durationEnd(start time.Time, d revenuecat.Duration) (*time.Time, bool) {
+	var end time.Time
+	switch d {
+	case revenuecat.Daily:
+		end = start.AddDate(0, 0, 1)
+	case revenuecat.Weekly:
+		end = start.AddDate(0, 0, 7)
+	case revenuecat.Monthly:
+		end = start.AddDate(0, 1, 0)
+	case revenuecat.TwoMonth:
+		end = start.AddDate(0, 2, 0)
+	case revenuecat.ThreeMonth:
+		end = start.AddDate(0, 3, 0)
+	case revenuecat.SixMonth:
+		end = start.AddDate(0, 6, 0)
+	case revenuecat.Yearly:
+		end = start.AddDate(1, 0, 0)
+	case revenuecat.Lifetime:
+		return nil, true
+	default:
+		return nil, false
+	}
+	return &end, true
+}
+
+// platformStore maps an X-Platform header to the store purchases are recorded against.
+func platformStore(platform string) revenuecat.Store {
+	switch strings.ToLower(platform) {
+	case "android", "play_store":
+		return revenuecat.PlayStore
+	case "macos", "mac_app_store":
+		return revenuecat.MacAppStore
+	case "stripe":
+		return revenuecat.StripeStore
+	}
+	return revenuecat.AppStore
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, revenuecat.ErrorCodeBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, code int, message string) {
+	writeJSON(w, status, revenuecat.Error{Code: code, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
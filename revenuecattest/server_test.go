@@ -0,0 +1,205 @@
+package revenuecattest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestGetSubscriberCreates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	rc := srv.Client()
+
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if sub.OriginalAppUserID != "123" || sub.FirstSeen.IsZero() {
+		t.Errorf("unexpected subscriber: %+v", sub)
+	}
+	if _, ok := srv.Subscriber("123"); !ok {
+		t.Error("expected subscriber to be stored")
+	}
+}
+
+func TestGrantAndRevokeEntitlement(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	rc := srv.Client()
+
+	if _, err := rc.GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !sub.IsEntitledTo("premium") {
+		t.Errorf("expected grant to be returned, got: %+v", sub.Entitlements)
+	}
+	if s := sub.Subscriptions["rc_promo_premium_monthly"]; s.Store != revenuecat.PromotionalStore {
+		t.Errorf("expected promotional subscription, got: %+v", sub.Subscriptions)
+	}
+
+	sub, err = rc.RevokeEntitlement("123", "premium")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if sub.IsEntitledTo("premium") {
+		t.Error("expected entitlement to be revoked")
+	}
+}
+
+func TestGrantLifetimeEntitlement(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	sub, err := srv.Client().GrantEntitlement("123", "premium", revenuecat.Lifetime, time.Time{})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if s := sub.Subscriptions["rc_promo_premium_lifetime"]; s.ExpiresDate != nil {
+		t.Errorf("expected no expiry, got: %v", s.ExpiresDate)
+	}
+	if !sub.Entitlements["premium"].IsLifetime() || !sub.IsEntitledTo("premium") {
+		t.Errorf("expected lifetime entitlement, got: %+v", sub.Entitlements)
+	}
+
+	// A lifetime grant outlives any subscription to the same entitlement.
+	if _, err := srv.Client().GrantEntitlement("123", "premium", revenuecat.Yearly, time.Time{}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if sub, _ := srv.Subscriber("123"); !sub.Entitlements["premium"].IsLifetime() {
+		t.Errorf("expected entitlement to stay lifetime, got: %+v", sub.Entitlements["premium"])
+	}
+}
+
+func TestGrantEntitlementInvalidDuration(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	_, err := srv.Client().GrantEntitlement("123", "premium", revenuecat.Duration("fortnight"), time.Time{})
+	if !errors.Is(err, revenuecat.Error{Code: revenuecat.ErrorCodeBadRequest, StatusCode: 400}) {
+		t.Errorf("expected bad request, got: %v", err)
+	}
+}
+
+func TestCreatePurchase(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddProduct("monthly", Product{Entitlements: []string{"premium"}, Duration: 30 * 24 * time.Hour})
+	srv.AddProduct("coins", Product{Entitlements: []string{"coins"}, NonRenewing: true})
+	rc := srv.Client(revenuecat.WithSandboxEnabled(true))
+
+	sub, err := rc.CreatePurchase("123", "receipt", &revenuecat.CreatePurchaseOptions{Platform: "android", ProductID: "monthly"})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	s := sub.Subscriptions["monthly"]
+	if s.Store != revenuecat.PlayStore || !s.IsSandbox || !sub.IsEntitledTo("premium") {
+		t.Errorf("unexpected subscriber: %+v", sub)
+	}
+
+	sub, err = rc.CreatePurchase("123", "receipt2", &revenuecat.CreatePurchaseOptions{Platform: "ios", ProductID: "coins"})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if len(sub.NonSubscriptions["coins"]) != 1 || !sub.IsEntitledTo("coins") || !sub.Entitlements["coins"].IsLifetime() {
+		t.Errorf("unexpected subscriber: %+v", sub)
+	}
+
+	_, err = rc.CreatePurchase("123", "", nil)
+	if !revenuecat.IsInvalidReceipt(err) {
+		t.Errorf("expected invalid receipt, got: %v", err)
+	}
+}
+
+func TestAttributesAndAttribution(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	rc := srv.Client()
+
+	err := rc.UpdateSubscriberAttributes("123", map[string]revenuecat.SubscriberAttribute{"$email": {Value: "user@example.com"}})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	sub, _ := rc.GetSubscriber("123")
+	if sub.SubscriberAttributes["$email"].Value != "user@example.com" {
+		t.Errorf("unexpected attributes: %+v", sub.SubscriberAttributes)
+	}
+
+	if err := rc.AddUserAttribution("123", revenuecat.Adjust, revenuecat.AttributionData{IDFA: "idfa"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if a := srv.Attributions("123"); len(a) != 1 || a[0].Network != revenuecat.Adjust || a[0].Data.IDFA != "idfa" {
+		t.Errorf("unexpected attributions: %+v", a)
+	}
+}
+
+func TestOfferingOverride(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	rc := srv.Client()
+
+	if _, err := rc.OverrideOffering("123", "offering_uuid"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if o := srv.OfferingOverride("123"); o != "offering_uuid" {
+		t.Errorf("expected override, got: %q", o)
+	}
+	if _, err := rc.DeleteOfferingOverride("123"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if o := srv.OfferingOverride("123"); o != "" {
+		t.Errorf("expected no override, got: %q", o)
+	}
+}
+
+func TestGoogleSubscriptions(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddProduct("monthly", Product{Entitlements: []string{"premium"}})
+	rc := srv.Client()
+
+	if _, err := rc.RefundGoogleSubscription("123", "monthly"); !revenuecat.IsNotFound(err) {
+		t.Errorf("expected not found, got: %v", err)
+	}
+
+	rc.CreatePurchase("123", "token", &revenuecat.CreatePurchaseOptions{Platform: "android", ProductID: "monthly"})
+	next := time.Now().Add(60 * 24 * time.Hour).Truncate(time.Millisecond)
+	sub, err := rc.DeferGoogleSubscription("123", "monthly", next)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if exp := sub.Subscriptions["monthly"].ExpiresDate; exp == nil || !exp.Equal(next) {
+		t.Errorf("expected expiry %v, got: %v", next, exp)
+	}
+
+	sub, err = rc.RefundGoogleSubscription("123", "monthly")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if sub.Subscriptions["monthly"].RefundedAt == nil || sub.IsEntitledTo("premium") {
+		t.Errorf("expected refunded subscription, got: %+v", sub)
+	}
+}
+
+func TestDeleteSubscriber(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	rc := srv.Client()
+
+	if err := rc.DeleteSubscriber("123"); !revenuecat.IsNotFound(err) {
+		t.Errorf("expected not found, got: %v", err)
+	}
+	rc.GetSubscriber("123")
+	if err := rc.DeleteSubscriber("123"); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if _, ok := srv.Subscriber("123"); ok {
+		t.Error("expected subscriber to be deleted")
+	}
+}
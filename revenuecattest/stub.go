@@ -0,0 +1,240 @@
+package revenuecattest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// Call is a recorded call to a Stub method. Method is the name without the Context suffix, and
+// Args holds the arguments after the context.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Stub is a configurable implementation of revenuecat.API that records every call.
+// Each method calls the matching func field, or returns a zero Subscriber and nil error if it is unset.
+// Methods with and without the Context suffix share a func field.
+type Stub struct {
+	GetSubscriberFunc              func(ctx context.Context, userID string) (revenuecat.Subscriber, error)
+	GetSubscriberWithPlatformFunc  func(ctx context.Context, userID string, platform string) (revenuecat.Subscriber, error)
+	UpdateSubscriberAttributesFunc func(ctx context.Context, userID string, attributes map[string]revenuecat.SubscriberAttribute) error
+	AddUserAttributionFunc         func(ctx context.Context, userID string, network revenuecat.Network, data revenuecat.AttributionData) error
+	DeleteSubscriberFunc           func(ctx context.Context, userID string) error
+	GrantEntitlementFunc           func(ctx context.Context, userID string, id string, duration revenuecat.Duration, startTime time.Time) (revenuecat.Subscriber, error)
+	RevokeEntitlementFunc          func(ctx context.Context, userID string, id string) (revenuecat.Subscriber, error)
+	CreatePurchaseFunc             func(ctx context.Context, userID string, receipt string, opt *revenuecat.CreatePurchaseOptions) (revenuecat.Subscriber, error)
+	OverrideOfferingFunc           func(ctx context.Context, userID string, offeringUUID string) (revenuecat.Subscriber, error)
+	DeleteOfferingOverrideFunc     func(ctx context.Context, userID string) (revenuecat.Subscriber, error)
+	RefundGoogleSubscriptionFunc   func(ctx context.Context, userID string, id string) (revenuecat.Subscriber, error)
+	DeferGoogleSubscriptionFunc    func(ctx context.Context, userID string, id string, nextExpiry time.Time) (revenuecat.Subscriber, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ revenuecat.API = (*Stub)(nil)
+
+// Calls returns every call made so far, in order.
+func (s *Stub) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the calls made to method, in order.
+func (s *Stub) CallsTo(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (s *Stub) Reset() {
+	s.mu.Lock()
+	s.calls = nil
+	s.mu.Unlock()
+}
+
+func (s *Stub) record(method string, args ...interface{}) {
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Args: args})
+	s.mu.Unlock()
+}
+
+// GetSubscriber implements revenuecat.SubscriberReader.
+func (s *Stub) GetSubscriber(userID string) (revenuecat.Subscriber, error) {
+	return s.GetSubscriberContext(context.Background(), userID)
+}
+
+// GetSubscriberContext implements revenuecat.SubscriberReader.
+func (s *Stub) GetSubscriberContext(ctx context.Context, userID string) (revenuecat.Subscriber, error) {
+	s.record("GetSubscriber", userID)
+	if s.GetSubscriberFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.GetSubscriberFunc(ctx, userID)
+}
+
+// GetSubscriberWithPlatform implements revenuecat.SubscriberReader.
+func (s *Stub) GetSubscriberWithPlatform(userID string, platform string) (revenuecat.Subscriber, error) {
+	return s.GetSubscriberWithPlatformContext(context.Background(), userID, platform)
+}
+
+// GetSubscriberWithPlatformContext implements revenuecat.SubscriberReader.
+func (s *Stub) GetSubscriberWithPlatformContext(ctx context.Context, userID string, platform string) (revenuecat.Subscriber, error) {
+	s.record("GetSubscriberWithPlatform", userID, platform)
+	if s.GetSubscriberWithPlatformFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.GetSubscriberWithPlatformFunc(ctx, userID, platform)
+}
+
+// UpdateSubscriberAttributes implements revenuecat.SubscriberManager.
+func (s *Stub) UpdateSubscriberAttributes(userID string, attributes map[string]revenuecat.SubscriberAttribute) error {
+	return s.UpdateSubscriberAttributesContext(context.Background(), userID, attributes)
+}
+
+// UpdateSubscriberAttributesContext implements revenuecat.SubscriberManager.
+func (s *Stub) UpdateSubscriberAttributesContext(ctx context.Context, userID string, attributes map[string]revenuecat.SubscriberAttribute) error {
+	s.record("UpdateSubscriberAttributes", userID, attributes)
+	if s.UpdateSubscriberAttributesFunc == nil {
+		return nil
+	}
+	return s.UpdateSubscriberAttributesFunc(ctx, userID, attributes)
+}
+
+// AddUserAttribution implements revenuecat.SubscriberManager.
+func (s *Stub) AddUserAttribution(userID string, network revenuecat.Network, data revenuecat.AttributionData) error {
+	return s.AddUserAttributionContext(context.Background(), userID, network, data)
+}
+
+// AddUserAttributionContext implements revenuecat.SubscriberManager.
+func (s *Stub) AddUserAttributionContext(ctx context.Context, userID string, network revenuecat.Network, data revenuecat.AttributionData) error {
+	s.record("AddUserAttribution", userID, network, data)
+	if s.AddUserAttributionFunc == nil {
+		return nil
+	}
+	return s.AddUserAttributionFunc(ctx, userID, network, data)
+}
+
+// DeleteSubscriber implements revenuecat.SubscriberManager.
+func (s *Stub) DeleteSubscriber(userID string) error {
+	return s.DeleteSubscriberContext(context.Background(), userID)
+}
+
+// DeleteSubscriberContext implements revenuecat.SubscriberManager.
+func (s *Stub) DeleteSubscriberContext(ctx context.Context, userID string) error {
+	s.record("DeleteSubscriber", userID)
+	if s.DeleteSubscriberFunc == nil {
+		return nil
+	}
+	return s.DeleteSubscriberFunc(ctx, userID)
+}
+
+// GrantEntitlement implements revenuecat.EntitlementManager.
+func (s *Stub) GrantEntitlement(userID string, id string, duration revenuecat.Duration, startTime time.Time) (revenuecat.Subscriber, error) {
+	return s.GrantEntitlementContext(context.Background(), userID, id, duration, startTime)
+}
+
+// GrantEntitlementContext implements revenuecat.EntitlementManager.
+func (s *Stub) GrantEntitlementContext(ctx context.Context, userID string, id string, duration revenuecat.Duration, startTime time.Time) (revenuecat.Subscriber, error) {
+	s.record("GrantEntitlement", userID, id, duration, startTime)
+	if s.GrantEntitlementFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.GrantEntitlementFunc(ctx, userID, id, duration, startTime)
+}
+
+// RevokeEntitlement implements revenuecat.EntitlementManager.
+func (s *Stub) RevokeEntitlement(userID string, id string) (revenuecat.Subscriber, error) {
+	return s.RevokeEntitlementContext(context.Background(), userID, id)
+}
+
+// RevokeEntitlementContext implements revenuecat.EntitlementManager.
+func (s *Stub) RevokeEntitlementContext(ctx context.Context, userID string, id string) (revenuecat.Subscriber, error) {
+	s.record("RevokeEntitlement", userID, id)
+	if s.RevokeEntitlementFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.RevokeEntitlementFunc(ctx, userID, id)
+}
+
+// CreatePurchase implements revenuecat.PurchaseRecorder.
+func (s *Stub) CreatePurchase(userID string, receipt string, opt *revenuecat.CreatePurchaseOptions) (revenuecat.Subscriber, error) {
+	return s.CreatePurchaseContext(context.Background(), userID, receipt, opt)
+}
+
+// CreatePurchaseContext implements revenuecat.PurchaseRecorder.
+func (s *Stub) CreatePurchaseContext(ctx context.Context, userID string, receipt string, opt *revenuecat.CreatePurchaseOptions) (revenuecat.Subscriber, error) {
+	s.record("CreatePurchase", userID, receipt, opt)
+	if s.CreatePurchaseFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.CreatePurchaseFunc(ctx, userID, receipt, opt)
+}
+
+// OverrideOffering implements revenuecat.OfferingManager.
+func (s *Stub) OverrideOffering(userID string, offeringUUID string) (revenuecat.Subscriber, error) {
+	return s.OverrideOfferingContext(context.Background(), userID, offeringUUID)
+}
+
+// OverrideOfferingContext implements revenuecat.OfferingManager.
+func (s *Stub) OverrideOfferingContext(ctx context.Context, userID string, offeringUUID string) (revenuecat.Subscriber, error) {
+	s.record("OverrideOffering", userID, offeringUUID)
+	if s.OverrideOfferingFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.OverrideOfferingFunc(ctx, userID, offeringUUID)
+}
+
+// DeleteOfferingOverride implements revenuecat.OfferingManager.
+func (s *Stub) DeleteOfferingOverride(userID string) (revenuecat.Subscriber, error) {
+	return s.DeleteOfferingOverrideContext(context.Background(), userID)
+}
+
+// DeleteOfferingOverrideContext implements revenuecat.OfferingManager.
+func (s *Stub) DeleteOfferingOverrideContext(ctx context.Context, userID string) (revenuecat.Subscriber, error) {
+	s.record("DeleteOfferingOverride", userID)
+	if s.DeleteOfferingOverrideFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.DeleteOfferingOverrideFunc(ctx, userID)
+}
+
+// RefundGoogleSubscription implements revenuecat.GoogleSubscriptionManager.
+func (s *Stub) RefundGoogleSubscription(userID string, id string) (revenuecat.Subscriber, error) {
+	return s.RefundGoogleSubscriptionContext(context.Background(), userID, id)
+}
+
+// RefundGoogleSubscriptionContext implements revenuecat.GoogleSubscriptionManager.
+func (s *Stub) RefundGoogleSubscriptionContext(ctx context.Context, userID string, id string) (revenuecat.Subscriber, error) {
+	s.record("RefundGoogleSubscription", userID, id)
+	if s.RefundGoogleSubscriptionFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.RefundGoogleSubscriptionFunc(ctx, userID, id)
+}
+
+// DeferGoogleSubscription implements revenuecat.GoogleSubscriptionManager.
+func (s *Stub) DeferGoogleSubscription(userID string, id string, nextExpiry time.Time) (revenuecat.Subscriber, error) {
+	return s.DeferGoogleSubscriptionContext(context.Background(), userID, id, nextExpiry)
+}
+
+// DeferGoogleSubscriptionContext implements revenuecat.GoogleSubscriptionManager.
+func (s *Stub) DeferGoogleSubscriptionContext(ctx context.Context, userID string, id string, nextExpiry time.Time) (revenuecat.Subscriber, error) {
+	s.record("DeferGoogleSubscription", userID, id, nextExpiry)
+	if s.DeferGoogleSubscriptionFunc == nil {
+		return revenuecat.Subscriber{}, nil
+	}
+	return s.DeferGoogleSubscriptionFunc(ctx, userID, id, nextExpiry)
+}
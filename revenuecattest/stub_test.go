@@ -0,0 +1,61 @@
+package revenuecattest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestStubRecordsCalls(t *testing.T) {
+	stub := &Stub{}
+	var api revenuecat.API = stub
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.GrantEntitlement("123", "premium", revenuecat.Monthly, start)
+	api.GetSubscriberContext(context.Background(), "123")
+	api.DeleteSubscriber("456")
+
+	want := []Call{
+		{Method: "GrantEntitlement", Args: []interface{}{"123", "premium", revenuecat.Monthly, start}},
+		{Method: "GetSubscriber", Args: []interface{}{"123"}},
+		{Method: "DeleteSubscriber", Args: []interface{}{"456"}},
+	}
+	if got := stub.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected calls %+v, got %+v", want, got)
+	}
+	if got := stub.CallsTo("GetSubscriber"); len(got) != 1 {
+		t.Errorf("expected 1 GetSubscriber call, got %+v", got)
+	}
+
+	stub.Reset()
+	if got := stub.Calls(); len(got) != 0 {
+		t.Errorf("expected no calls after reset, got %+v", got)
+	}
+}
+
+func TestStubFuncs(t *testing.T) {
+	wantErr := errors.New("boom")
+	stub := &Stub{
+		GetSubscriberFunc: func(ctx context.Context, userID string) (revenuecat.Subscriber, error) {
+			return revenuecat.Subscriber{OriginalAppUserID: userID}, nil
+		},
+		DeleteSubscriberFunc: func(ctx context.Context, userID string) error {
+			return wantErr
+		},
+	}
+
+	sub, err := stub.GetSubscriber("123")
+	if err != nil || sub.OriginalAppUserID != "123" {
+		t.Errorf("unexpected result: %+v, %v", sub, err)
+	}
+	if err := stub.DeleteSubscriber("123"); err != wantErr {
+		t.Errorf("expected %v, got %v", wantErr, err)
+	}
+	if _, err := stub.RevokeEntitlement("123", "premium"); err != nil {
+		t.Errorf("expected unset func to return nil error, got %v", err)
+	}
+}
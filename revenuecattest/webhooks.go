@@ -0,0 +1,157 @@
+package revenuecattest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/webhook"
+)
+
+// SetWebhook makes the server POST webhook events to url whenever a subscriber's purchases change,
+// sending authorization as the Authorization header. Events are delivered synchronously, before the
+// API response or helper call that caused them returns. An empty url disables webhooks.
+func (s *Server) SetWebhook(url, authorization string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhookURL = url
+	s.webhookAuthorization = authorization
+}
+
+// WebhookErrors returns the errors from failed webhook deliveries.
+func (s *Server) WebhookErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.webhookErrs...)
+}
+
+// Tick applies the store lifecycle up to the clock's current time and delivers any resulting webhooks.
+// It is called implicitly by every request and helper, so it is only needed to observe webhooks
+// after moving the clock without otherwise using the server.
+func (s *Server) Tick() {
+	s.mu.Lock()
+	s.unlock()
+}
+
+// unlock applies the store lifecycle, releases s.mu and then delivers the webhooks queued while it was held.
+func (s *Server) unlock() {
+	s.tick()
+	events := s.pending
+	s.pending = nil
+	url, auth := s.webhookURL, s.webhookAuthorization
+	s.mu.Unlock()
+
+	for _, e := range events {
+		if err := deliver(url, auth, e); err != nil {
+			s.mu.Lock()
+			s.webhookErrs = append(s.webhookErrs, err)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// emit queues a webhook event for the subscriber's product. s.mu must be held.
+func (s *Server) emit(typ webhook.EventType, state *subscriber, productID string, fn func(e *webhook.Event)) {
+	if s.webhookURL == "" {
+		return
+	}
+	s.eventID++
+	userID := state.sub.OriginalAppUserID
+	e := webhook.Event{
+		ID:                fmt.Sprintf("evt_%d", s.eventID),
+		Type:              typ,
+		EventTimestamp:    s.now(),
+		AppUserID:         userID,
+		OriginalAppUserID: userID,
+		Aliases:           []string{userID},
+		ProductID:         productID,
+		EntitlementIDs:    state.entitlements[productID],
+		Environment:       webhook.Production,
+	}
+	if sub, ok := state.sub.Subscriptions[productID]; ok {
+		e.PeriodType = sub.PeriodType
+		e.PurchasedAt = sub.PurchaseDate
+		e.ExpirationAt = sub.ExpiresDate
+		e.GracePeriodExpirationAt = sub.GracePeriodExpiresDate
+		e.AutoResumeAt = sub.AutoResumeDate
+		e.Store = sub.Store
+		e.TransactionID = sub.StoreTransactionID
+		if sub.IsSandbox {
+			e.Environment = webhook.Sandbox
+		}
+		switch {
+		case sub.RefundedAt != nil:
+			e.CancelReason = webhook.CustomerSupport
+		case sub.BillingIssuesDetectedAt != nil:
+			e.CancelReason = webhook.BillingError
+		default:
+			e.CancelReason = webhook.Unsubscribe
+		}
+		if typ == webhook.Expiration {
+			e.ExpirationReason = e.CancelReason
+		}
+		if typ != webhook.Cancellation {
+			e.CancelReason = ""
+		}
+	}
+	if fn != nil {
+		fn(&e)
+	}
+	s.pending = append(s.pending, e)
+}
+
+// emitExpirations queues an EXPIRATION event for every subscription of state that has lapsed since
+// it was last active. s.mu must be held.
+func (s *Server) emitExpirations(state *subscriber) {
+	now := s.now()
+	for productID, sub := range state.sub.Subscriptions {
+		if sub.Store == revenuecat.PromotionalStore || sub.ExpiresDate == nil {
+			continue
+		}
+		if now.Before(*sub.ExpiresDate) {
+			delete(state.expired, productID)
+			continue
+		}
+		lapsed := sub.AutoResumeDate == nil &&
+			(sub.GracePeriodExpiresDate == nil || !now.Before(*sub.GracePeriodExpiresDate))
+		if lapsed && !state.expired[productID] {
+			state.expired[productID] = true
+			s.emit(webhook.Expiration, state, productID, nil)
+		}
+	}
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+func deliver(url, authorization string, e webhook.Event) error {
+	body, err := json.Marshal(struct {
+		APIVersion string        `json:"api_version"`
+		Event      webhook.Event `json:"event"`
+	}{
+		APIVersion: "1.0",
+		Event:      e,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling webhook %s: %v", e.ID, err)
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook %s: %v", e.ID, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error delivering webhook %s: %v", e.ID, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error delivering webhook %s: status %d", e.ID, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package revenuecattest
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+	"github.com/mhemmings/revenuecat/v2/webhook"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []webhook.Event
+}
+
+func (r *eventRecorder) record(ctx context.Context, e webhook.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *eventRecorder) types() []webhook.EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []webhook.EventType
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func (r *eventRecorder) last() webhook.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func newWebhookServer(t *testing.T) (*Server, *Clock, *eventRecorder, *webhook.Projector) {
+	t.Helper()
+	rec := &eventRecorder{}
+	projector := webhook.NewProjector()
+	h := webhook.NewHandler("Bearer secret", webhook.WithDefaultCallback(func(ctx context.Context, e webhook.Event) error {
+		rec.record(ctx, e)
+		return projector.Handle(ctx, e)
+	}))
+	consumer := httptest.NewServer(h)
+	t.Cleanup(consumer.Close)
+
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	clock := NewClock(time.Now().Truncate(time.Millisecond))
+	srv.SetClock(clock)
+	srv.SetWebhook(consumer.URL, "Bearer secret")
+	srv.AddProduct("monthly", Product{Entitlements: []string{"premium"}, Duration: 30 * day, TrialDuration: 7 * day})
+	return srv, clock, rec, projector
+}
+
+func TestWebhooksSubscriptionLifecycle(t *testing.T) {
+	srv, clock, rec, projector := newWebhookServer(t)
+	rc := srv.Client(revenuecat.WithSandboxEnabled(true))
+
+	if _, err := rc.CreatePurchase("123", "token", &revenuecat.CreatePurchaseOptions{ProductID: "monthly"}); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	e := rec.last()
+	if e.Type != webhook.InitialPurchase || e.AppUserID != "123" || e.PeriodType != revenuecat.TrialPeriodType ||
+		!e.IsSandbox() || !reflect.DeepEqual(e.EntitlementIDs, []string{"premium"}) {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if sub, ok := projector.Subscriber("123"); !ok || !sub.Entitlements["premium"].ExpiresDate.After(clock.Now()) {
+		t.Error("expected the projection to be entitled")
+	}
+
+	clock.Advance(7 * day)
+	srv.Tick()
+	if e := rec.last(); e.Type != webhook.Renewal || !e.IsTrialConversion {
+		t.Errorf("expected trial conversion, got: %+v", e)
+	}
+
+	srv.Cancel("123", "monthly")
+	if e := rec.last(); e.Type != webhook.Cancellation || e.CancelReason != webhook.Unsubscribe {
+		t.Errorf("expected cancellation, got: %+v", e)
+	}
+
+	clock.Advance(30 * day)
+	srv.Tick()
+	if e := rec.last(); e.Type != webhook.Expiration || e.ExpirationReason != webhook.Unsubscribe {
+		t.Errorf("expected expiration, got: %+v", e)
+	}
+	if sub, _ := projector.Subscriber("123"); sub.Entitlements["premium"].ExpiresDate.After(clock.Now()) {
+		t.Error("expected the projection to have expired")
+	}
+
+	expected := []webhook.EventType{webhook.InitialPurchase, webhook.Renewal, webhook.Cancellation, webhook.Expiration}
+	if types := rec.types(); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected events %v, got: %v", expected, types)
+	}
+	if errs := srv.WebhookErrors(); len(errs) != 0 {
+		t.Errorf("unexpected webhook errors: %v", errs)
+	}
+}
+
+func TestWebhooksPromotional(t *testing.T) {
+	srv, _, rec, _ := newWebhookServer(t)
+	rc := srv.Client()
+
+	rc.GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{})
+	if e := rec.last(); e.Type != webhook.NonRenewingPurchase || e.Store != revenuecat.PromotionalStore {
+		t.Errorf("expected promotional purchase, got: %+v", e)
+	}
+	rc.RevokeEntitlement("123", "premium")
+	if e := rec.last(); e.Type != webhook.Expiration || e.ExpirationReason != webhook.DeveloperInitiated {
+		t.Errorf("expected developer initiated expiration, got: %+v", e)
+	}
+}
+
+func TestWebhooksRefund(t *testing.T) {
+	srv, _, rec, _ := newWebhookServer(t)
+	rc := srv.Client()
+
+	rc.CreatePurchase("123", "token", &revenuecat.CreatePurchaseOptions{Platform: "android", ProductID: "monthly"})
+	rc.RefundGoogleSubscription("123", "monthly")
+
+	expected := []webhook.EventType{webhook.InitialPurchase, webhook.Cancellation, webhook.Expiration}
+	if types := rec.types(); !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected events %v, got: %v", expected, types)
+	}
+	if e := rec.last(); e.ExpirationReason != webhook.CustomerSupport {
+		t.Errorf("expected customer support expiration, got: %+v", e)
+	}
+}
+
+func TestWebhooksDeliveryErrors(t *testing.T) {
+	srv, _, _, _ := newWebhookServer(t)
+	srv.SetWebhook(srv.APIURL()+"not_found", "Bearer secret")
+
+	srv.Client().GrantEntitlement("123", "premium", revenuecat.Monthly, time.Time{})
+	if errs := srv.WebhookErrors(); len(errs) != 1 {
+		t.Errorf("expected 1 webhook error, got: %v", errs)
+	}
+}
@@ -0,0 +1,93 @@
+package revenuecat
+
+import "time"
+
+// SubscriptionStatus is the state of a subscription, derived from its dates.
+type SubscriptionStatus string
+
+const (
+	// SubscriptionActiveRenewing means the subscription is active and will renew.
+	SubscriptionActiveRenewing SubscriptionStatus = "active_renewing"
+	// SubscriptionActiveCancelled means the subscription is active until it expires, but won't renew.
+	// Promotional subscriptions never renew, so they are always cancelled while active.
+	SubscriptionActiveCancelled SubscriptionStatus = "active_cancelled"
+	// SubscriptionInTrial means the subscription is in a free trial.
+	SubscriptionInTrial SubscriptionStatus = "in_trial"
+	// SubscriptionInGracePeriod means a renewal failed but the store keeps access while it retries.
+	SubscriptionInGracePeriod SubscriptionStatus = "in_grace_period"
+	// SubscriptionBillingRetry means a renewal failed and access has ended, but the store is still retrying.
+	SubscriptionBillingRetry SubscriptionStatus = "billing_retry"
+	// SubscriptionPaused means the subscription is paused until its AutoResumeDate.
+	SubscriptionPaused SubscriptionStatus = "paused"
+	// SubscriptionRefunded means the subscription was refunded.
+	SubscriptionRefunded SubscriptionStatus = "refunded"
+	// SubscriptionExpired means the subscription has expired.
+	SubscriptionExpired SubscriptionStatus = "expired"
+)
+
+// Status returns the status of the subscription now.
+func (s Subscription) Status() SubscriptionStatus {
+	return s.StatusAt(time.Now())
+}
+
+// StatusAt returns the status of the subscription at now.
+func (s Subscription) StatusAt(now time.Time) SubscriptionStatus {
+	if s.RefundedAt != nil && !s.RefundedAt.After(now) {
+		return SubscriptionRefunded
+	}
+
+	if s.ExpiresDate != nil && s.ExpiresDate.Before(now) {
+		switch {
+		case s.GracePeriodExpiresDate != nil && now.Before(*s.GracePeriodExpiresDate):
+			return SubscriptionInGracePeriod
+		case s.AutoResumeDate != nil && now.Before(*s.AutoResumeDate):
+			return SubscriptionPaused
+		case s.BillingIssuesDetectedAt != nil && s.UnsubscribeDetectedAt == nil:
+			return SubscriptionBillingRetry
+		}
+		return SubscriptionExpired
+	}
+
+	switch {
+	case s.BillingIssuesDetectedAt != nil:
+		// RevenueCat extends the expiry to the end of the grace period while the store retries.
+		return SubscriptionInGracePeriod
+	case s.PeriodType == TrialPeriodType:
+		return SubscriptionInTrial
+	case s.UnsubscribeDetectedAt != nil || s.Store == PromotionalStore:
+		return SubscriptionActiveCancelled
+	}
+	return SubscriptionActiveRenewing
+}
+
+// WillRenew returns true if the store will try to renew the subscription, including after a pause
+// or a failed renewal.
+func (s Subscription) WillRenew() bool {
+	return s.WillRenewAt(time.Now())
+}
+
+// WillRenewAt is like WillRenew but uses t as the current time.
+func (s Subscription) WillRenewAt(t time.Time) bool {
+	switch s.StatusAt(t) {
+	case SubscriptionActiveRenewing, SubscriptionBillingRetry, SubscriptionPaused:
+		return true
+	case SubscriptionInTrial, SubscriptionInGracePeriod:
+		return s.UnsubscribeDetectedAt == nil && s.Store != PromotionalStore
+	}
+	return false
+}
+
+// IsTrial returns true if the current period is a free trial.
+func (s Subscription) IsTrial() bool {
+	return s.PeriodType == TrialPeriodType
+}
+
+// IsPaused returns true if the subscription is paused now.
+func (s Subscription) IsPaused() bool {
+	return s.IsPausedAt(time.Now())
+}
+
+// IsPausedAt returns true if the subscription is paused at t.
+func (s Subscription) IsPausedAt(t time.Time) bool {
+	return s.StatusAt(t) == SubscriptionPaused
+}
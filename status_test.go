@@ -0,0 +1,113 @@
+package revenuecat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptionStatus(t *testing.T) {
+	now := staticTime(t, "2020-02-16 00:00:00")
+	past, future := timePtr(now.Add(-time.Hour)), timePtr(now.Add(time.Hour))
+
+	tests := []struct {
+		name      string
+		sub       Subscription
+		status    SubscriptionStatus
+		willRenew bool
+	}{
+		{"renewing", Subscription{ExpiresDate: future}, SubscriptionActiveRenewing, true},
+		{"cancelled", Subscription{ExpiresDate: future, UnsubscribeDetectedAt: past}, SubscriptionActiveCancelled, false},
+		{"promotional", Subscription{ExpiresDate: future, Store: PromotionalStore}, SubscriptionActiveCancelled, false},
+		{"lifetime promotional", Subscription{Store: PromotionalStore}, SubscriptionActiveCancelled, false},
+		{"trial", Subscription{ExpiresDate: future, PeriodType: TrialPeriodType}, SubscriptionInTrial, true},
+		{"cancelled trial", Subscription{ExpiresDate: future, PeriodType: TrialPeriodType, UnsubscribeDetectedAt: past}, SubscriptionInTrial, false},
+		{"grace period", Subscription{ExpiresDate: past, BillingIssuesDetectedAt: past, GracePeriodExpiresDate: future}, SubscriptionInGracePeriod, true},
+		{"grace period extended expiry", Subscription{ExpiresDate: future, BillingIssuesDetectedAt: past}, SubscriptionInGracePeriod, true},
+		{"billing retry", Subscription{ExpiresDate: past, BillingIssuesDetectedAt: past}, SubscriptionBillingRetry, true},
+		{"billing retry given up", Subscription{ExpiresDate: past, BillingIssuesDetectedAt: past, UnsubscribeDetectedAt: past}, SubscriptionExpired, false},
+		{"paused", Subscription{ExpiresDate: past, AutoResumeDate: future}, SubscriptionPaused, true},
+		{"pause scheduled", Subscription{ExpiresDate: future, AutoResumeDate: timePtr(now.Add(2 * time.Hour))}, SubscriptionActiveRenewing, true},
+		{"refunded", Subscription{ExpiresDate: past, RefundedAt: past}, SubscriptionRefunded, false},
+		{"expired", Subscription{ExpiresDate: past}, SubscriptionExpired, false},
+		{"expired after grace period", Subscription{ExpiresDate: past, GracePeriodExpiresDate: past, UnsubscribeDetectedAt: past}, SubscriptionExpired, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := tt.sub.StatusAt(now); status != tt.status {
+				t.Errorf("expected status %s, got %s", tt.status, status)
+			}
+			if willRenew := tt.sub.WillRenewAt(now); willRenew != tt.willRenew {
+				t.Errorf("expected WillRenew %v, got %v", tt.willRenew, willRenew)
+			}
+		})
+	}
+}
+
+func TestSubscriptionStatusAt(t *testing.T) {
+	purchased := staticTime(t, "2020-01-01 00:00:00")
+	expires := staticTime(t, "2020-02-01 00:00:00")
+	sub := Subscription{PurchaseDate: purchased, ExpiresDate: &expires, UnsubscribeDetectedAt: &purchased}
+
+	tests := []struct {
+		at     time.Time
+		status SubscriptionStatus
+	}{
+		{staticTime(t, "2020-01-15 00:00:00"), SubscriptionActiveCancelled},
+		{expires, SubscriptionActiveCancelled},
+		{staticTime(t, "2020-02-01 00:00:01"), SubscriptionExpired},
+	}
+	for _, tt := range tests {
+		if status := sub.StatusAt(tt.at); status != tt.status {
+			t.Errorf("at %v: expected %s, got %s", tt.at, tt.status, status)
+		}
+	}
+}
+
+func TestSubscriptionIsTrial(t *testing.T) {
+	tests := []struct {
+		periodType PeriodType
+		expected   bool
+	}{
+		{TrialPeriodType, true},
+		{IntroPeriodType, false},
+		{NormalPeriodType, false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if res := (Subscription{PeriodType: tt.periodType}).IsTrial(); res != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.periodType, tt.expected, res)
+		}
+	}
+}
+
+func TestSubscriptionIsPaused(t *testing.T) {
+	now := staticTime(t, "2020-02-16 00:00:00")
+	past, future := timePtr(now.Add(-time.Hour)), timePtr(now.Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		sub      Subscription
+		expected bool
+	}{
+		{"paused", Subscription{ExpiresDate: past, AutoResumeDate: future}, true},
+		{"resumed", Subscription{ExpiresDate: past, AutoResumeDate: past}, false},
+		{"pause scheduled", Subscription{ExpiresDate: future, AutoResumeDate: future}, false},
+		{"active", Subscription{ExpiresDate: future}, false},
+		{"refunded while paused", Subscription{ExpiresDate: past, AutoResumeDate: future, RefundedAt: past}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := tt.sub.IsPausedAt(now); res != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestSubscriptionNowWrappers(t *testing.T) {
+	now := time.Now()
+	paused := Subscription{ExpiresDate: timePtr(now.Add(-time.Hour)), AutoResumeDate: timePtr(now.Add(time.Hour))}
+	if !paused.IsPaused() || !paused.WillRenew() || paused.Status() != SubscriptionPaused {
+		t.Errorf("expected a paused subscription that will renew, got %s", paused.Status())
+	}
+}
@@ -1,7 +1,9 @@
 package revenuecat
 
 import (
+	"context"
 	"encoding/json"
+	"sort"
 	"time"
 )
 
@@ -15,15 +17,39 @@ type Subscriber struct {
 	Subscriptions              map[string]Subscription        `json:"subscriptions"`
 	NonSubscriptions           map[string][]NonSubscription   `json:"non_subscriptions"`
 	SubscriberAttributes       map[string]SubscriberAttribute `json:"subscriber_attributes"`
+	ManagementURL              *string                        `json:"management_url"`
+	OriginalPurchaseDate       *time.Time                     `json:"original_purchase_date"`
+	OtherPurchases             map[string]OtherPurchase       `json:"other_purchases"`
+
+	// RequestDate is the server time of the response the Subscriber was returned in. Evaluating entitlements
+	// at RequestDate gives the answer RevenueCat would, regardless of local clock drift.
+	RequestDate time.Time `json:"-"`
+}
+
+// subscriberResponse is the body of responses that return a Subscriber.
+type subscriberResponse struct {
+	RequestDateMs int64      `json:"request_date_ms"`
+	Subscriber    Subscriber `json:"subscriber"`
+}
+
+// subscriber returns the Subscriber with its RequestDate set.
+func (r subscriberResponse) subscriber() Subscriber {
+	sub := r.Subscriber
+	if r.RequestDateMs > 0 {
+		sub.RequestDate = FromMilliseconds(r.RequestDateMs)
+	}
+	return sub
 }
 
 // https://docs.revenuecat.com/reference#the-entitlement-object
 type Entitlement struct {
-	ExpiresDate            time.Time  `json:"expires_date"`
+	// ExpiresDate is nil for lifetime purchases and non-expiring promotional grants.
+	ExpiresDate            *time.Time `json:"expires_date"`
 	GracePeriodExpiresDate *time.Time `json:"grace_period_expires_date"`
 	PurchaseDate           time.Time  `json:"purchase_date"`
 	ProductIdentifier      string     `json:"product_identifier"`
 	ProductPlanIdentifier  string     `json:"product_plan_identifier"`
+	IsSandbox              bool       `json:"is_sandbox"`
 }
 
 // https://docs.revenuecat.com/reference#the-subscription-object
@@ -42,14 +68,28 @@ type Subscription struct {
 	OwnershipType           OwnershipType `json:"ownership_type"`
 	StoreTransactionID      string        `json:"store_transaction_id"`
 	ProductPlanIdentifier   string        `json:"product_plan_identifier"`
+	DisplayName             *string       `json:"display_name"`
+	Price                   *Price        `json:"price"`
+}
+
+// Price holds the price a subscription was purchased at.
+type Price struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
 }
 
 // https://docs.revenuecat.com/reference#section-the-non-subscription-object
 type NonSubscription struct {
-	ID           string    `json:"id"`
+	ID                 string    `json:"id"`
+	PurchaseDate       time.Time `json:"purchase_date"`
+	Store              Store     `json:"store"`
+	IsSandbox          bool      `json:"is_sandbox"`
+	StoreTransactionID string    `json:"store_transaction_id"`
+}
+
+// OtherPurchase holds a purchase that isn't a subscription, keyed by product identifier in Subscriber.OtherPurchases.
+type OtherPurchase struct {
 	PurchaseDate time.Time `json:"purchase_date"`
-	Store        Store     `json:"store"`
-	IsSandbox    bool      `json:"is_sandbox"`
 }
 
 // https://docs.revenuecat.com/reference#section-the-subscriber-attribute-object
@@ -88,53 +128,229 @@ const (
 	PromotionalStore Store = "promotional"
 )
 
-// IsEntitledTo returns true if the Subscriber has the given entitlement.
+// IsLifetime returns true if the Entitlement never expires.
+func (e Entitlement) IsLifetime() bool {
+	return e.ExpiresDate == nil
+}
+
+// ExpiresAfter returns true if the Entitlement expires after expires, where nil means never.
+// A lifetime entitlement expires after any date except never.
+func (e Entitlement) ExpiresAfter(expires *time.Time) bool {
+	if e.ExpiresDate == nil || expires == nil {
+		return e.ExpiresDate == nil && expires != nil
+	}
+	return e.ExpiresDate.After(*expires)
+}
+
+// IsActiveAt returns true if the Entitlement hasn't expired at t. Lifetime entitlements are always active.
+func (e Entitlement) IsActiveAt(t time.Time) bool {
+	return e.IsLifetime() || !e.ExpiresDate.Before(t)
+}
+
+// IsEntitledTo returns true if the Subscriber has the given entitlement. Lifetime entitlements are always active.
 func (s Subscriber) IsEntitledTo(entitlement string) bool {
+	return s.IsEntitledToAt(entitlement, time.Now())
+}
+
+// IsEntitledToAt is like IsEntitledTo but checks the entitlement at t instead of now.
+// Pass RequestDate to use the server's time.
+func (s Subscriber) IsEntitledToAt(entitlement string, t time.Time) bool {
 	e, ok := s.Entitlements[entitlement]
-	if !ok {
+	return ok && e.IsActiveAt(t)
+}
+
+// IsActiveAt returns true if the Subscription hasn't expired at t. Subscriptions without an expiry are always active.
+func (s Subscription) IsActiveAt(t time.Time) bool {
+	return s.ExpiresDate == nil || !s.ExpiresDate.Before(t)
+}
+
+// ActiveEntitlements returns the sorted identifiers of the entitlements that are active at t, matching IsEntitledToAt.
+func (s Subscriber) ActiveEntitlements(t time.Time) []string {
+	var ids []string
+	for id, e := range s.Entitlements {
+		if e.IsActiveAt(t) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SubscriptionFilter narrows the subscriptions returned by ActiveSubscriptions.
+type SubscriptionFilter struct {
+	// Stores limits the subscriptions to these stores. Empty means every store.
+	Stores []Store
+	// SkipSandbox leaves out sandbox subscriptions.
+	SkipSandbox bool
+}
+
+func (f *SubscriptionFilter) match(sub Subscription) bool {
+	if f == nil {
+		return true
+	}
+	if f.SkipSandbox && sub.IsSandbox {
 		return false
 	}
-	return !e.ExpiresDate.Before(time.Now())
+	if len(f.Stores) == 0 {
+		return true
+	}
+	for _, store := range f.Stores {
+		if sub.Store == store {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveSubscriptions returns the subscriptions that are active at t, keyed by product identifier.
+// A nil filter returns every active subscription.
+func (s Subscriber) ActiveSubscriptions(t time.Time, filter *SubscriptionFilter) map[string]Subscription {
+	subs := make(map[string]Subscription)
+	for id, sub := range s.Subscriptions {
+		if sub.IsActiveAt(t) && filter.match(sub) {
+			subs[id] = sub
+		}
+	}
+	return subs
+}
+
+// HasAnyActive returns true if any entitlement or subscription is active at t.
+func (s Subscriber) HasAnyActive(t time.Time) bool {
+	for _, e := range s.Entitlements {
+		if e.IsActiveAt(t) {
+			return true
+		}
+	}
+	for _, sub := range s.Subscriptions {
+		if sub.IsActiveAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// LatestExpiration returns the latest expiry of the Subscriber's entitlements. The returned time is nil if
+// any entitlement is a lifetime entitlement, and ok is false if the Subscriber has no entitlements.
+func (s Subscriber) LatestExpiration() (expires *time.Time, ok bool) {
+	var latest time.Time
+	for _, e := range s.Entitlements {
+		if e.IsLifetime() {
+			return nil, true
+		}
+		if !ok || e.ExpiresDate.After(latest) {
+			latest = *e.ExpiresDate
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, false
+	}
+	return &latest, true
+}
+
+// SubscriptionFor returns the Subscription backing the given entitlement, found through the entitlement's
+// ProductIdentifier. It returns false if the entitlement is missing or is backed by a non-subscription purchase.
+func (s Subscriber) SubscriptionFor(entitlement string) (Subscription, bool) {
+	e, ok := s.Entitlements[entitlement]
+	if !ok {
+		return Subscription{}, false
+	}
+	sub, ok := s.Subscriptions[e.ProductIdentifier]
+	return sub, ok
+}
+
+// Clone returns a copy of the Subscriber that shares no maps or slices with the original.
+func (s Subscriber) Clone() Subscriber {
+	c := s
+	if s.Entitlements != nil {
+		c.Entitlements = make(map[string]Entitlement, len(s.Entitlements))
+		for k, v := range s.Entitlements {
+			c.Entitlements[k] = v
+		}
+	}
+	if s.Subscriptions != nil {
+		c.Subscriptions = make(map[string]Subscription, len(s.Subscriptions))
+		for k, v := range s.Subscriptions {
+			c.Subscriptions[k] = v
+		}
+	}
+	if s.NonSubscriptions != nil {
+		c.NonSubscriptions = make(map[string][]NonSubscription, len(s.NonSubscriptions))
+		for k, v := range s.NonSubscriptions {
+			c.NonSubscriptions[k] = append([]NonSubscription(nil), v...)
+		}
+	}
+	if s.SubscriberAttributes != nil {
+		c.SubscriberAttributes = make(map[string]SubscriberAttribute, len(s.SubscriberAttributes))
+		for k, v := range s.SubscriberAttributes {
+			c.SubscriberAttributes[k] = v
+		}
+	}
+	if s.OtherPurchases != nil {
+		c.OtherPurchases = make(map[string]OtherPurchase, len(s.OtherPurchases))
+		for k, v := range s.OtherPurchases {
+			c.OtherPurchases[k] = v
+		}
+	}
+	return c
 }
 
 // GetSubscriber gets the latest subscriber info or creates one if it doesn't exist.
 // https://docs.revenuecat.com/reference#subscribers
 func (c *Client) GetSubscriber(userID string) (Subscriber, error) {
-	return c.GetSubscriberWithPlatform(userID, "")
+	return c.GetSubscriberContext(context.Background(), userID)
+}
+
+// GetSubscriberContext is like GetSubscriber but uses ctx for the request.
+func (c *Client) GetSubscriberContext(ctx context.Context, userID string) (Subscriber, error) {
+	return c.GetSubscriberWithPlatformContext(ctx, userID, "")
 }
 
 // GetSubscriberWithPlatform gets the latest subscriber info or creates one if it doesn't exist, updating the subscriber record's last_seen
 // value for the platform provided.
 // https://docs.revenuecat.com/reference#subscribers
 func (c *Client) GetSubscriberWithPlatform(userID string, platform string) (Subscriber, error) {
-	var resp struct {
-		Subscriber Subscriber `json:"subscriber"`
-	}
-	err := c.call("GET", "subscribers/"+userID, nil, platform, &resp)
-	return resp.Subscriber, err
+	return c.GetSubscriberWithPlatformContext(context.Background(), userID, platform)
+}
+
+// GetSubscriberWithPlatformContext is like GetSubscriberWithPlatform but uses ctx for the request.
+func (c *Client) GetSubscriberWithPlatformContext(ctx context.Context, userID string, platform string) (Subscriber, error) {
+	var resp subscriberResponse
+	err := c.call(ctx, "GET", "subscribers/"+userID, nil, platform, &resp)
+	return resp.subscriber(), err
 }
 
 // UpdateSubscriberAttributes updates subscriber attributes for a user.
 // https://docs.revenuecat.com/reference#update-subscriber-attributes
 func (c *Client) UpdateSubscriberAttributes(userID string, attributes map[string]SubscriberAttribute) error {
+	return c.UpdateSubscriberAttributesContext(context.Background(), userID, attributes)
+}
+
+// UpdateSubscriberAttributesContext is like UpdateSubscriberAttributes but uses ctx for the request.
+func (c *Client) UpdateSubscriberAttributesContext(ctx context.Context, userID string, attributes map[string]SubscriberAttribute) error {
 	req := struct {
 		Attributes map[string]SubscriberAttribute `json:"attributes"`
 	}{
 		Attributes: attributes,
 	}
-	return c.call("POST", "subscribers/"+userID+"/attributes", req, "", nil)
+	return c.call(ctx, "POST", "subscribers/"+userID+"/attributes", req, "", nil)
 }
 
 // DeleteSubscriber permanently deletes a subscriber.
 // https://docs.revenuecat.com/reference#subscribersapp_user_id
 func (c *Client) DeleteSubscriber(userID string) error {
-	return c.call("DELETE", "subscribers/"+userID, nil, "", nil)
+	return c.DeleteSubscriberContext(context.Background(), userID)
+}
+
+// DeleteSubscriberContext is like DeleteSubscriber but uses ctx for the request.
+func (c *Client) DeleteSubscriberContext(ctx context.Context, userID string) error {
+	return c.call(ctx, "DELETE", "subscribers/"+userID, nil, "", nil)
 }
 
 func (attr SubscriberAttribute) MarshalJSON() ([]byte, error) {
 	var updatedAt int64
 	if !attr.UpdatedAt.IsZero() {
-		updatedAt = toMilliseconds(attr.UpdatedAt)
+		updatedAt = ToMilliseconds(attr.UpdatedAt)
 	}
 	return json.Marshal(&struct {
 		Value     string `json:"value"`
@@ -155,7 +371,7 @@ func (attr *SubscriberAttribute) UnmarshalJSON(data []byte) error {
 	}
 	attr.Value = jsonAttr.Value
 	if jsonAttr.UpdatedAt > 0 {
-		attr.UpdatedAt = fromMilliseconds(jsonAttr.UpdatedAt)
+		attr.UpdatedAt = FromMilliseconds(jsonAttr.UpdatedAt)
 	}
 	return nil
 }
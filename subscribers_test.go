@@ -2,10 +2,15 @@ package revenuecat
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"testing"
 	"time"
 )
 
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 func TestGetSubscriber(t *testing.T) {
 	cl := newMockClient(t, 200, nil, nil)
 	rc := New("apikey")
@@ -163,7 +168,7 @@ func TestSubscriberIsEntitledTo(t *testing.T) {
 		name: "expired",
 		sub: map[string]Entitlement{
 			"test": {
-				ExpiresDate: time.Now().Add(-time.Hour),
+				ExpiresDate: timePtr(time.Now().Add(-time.Hour)),
 			},
 		},
 		entitlement: "test",
@@ -172,11 +177,18 @@ func TestSubscriberIsEntitledTo(t *testing.T) {
 		name: "subscribed",
 		sub: map[string]Entitlement{
 			"test": {
-				ExpiresDate: time.Now().Add(time.Hour),
+				ExpiresDate: timePtr(time.Now().Add(time.Hour)),
 			},
 		},
 		entitlement: "test",
 		expected:    true,
+	}, {
+		name: "lifetime",
+		sub: map[string]Entitlement{
+			"test": {},
+		},
+		entitlement: "test",
+		expected:    true,
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -189,3 +201,272 @@ func TestSubscriberIsEntitledTo(t *testing.T) {
 		})
 	}
 }
+
+func TestEntitlementLifetimeUnmarshalJSON(t *testing.T) {
+	data := `{
+		"lifetime": {"expires_date": null, "product_identifier": "lifetime_unlock", "purchase_date": "2020-01-16T00:00:00Z"},
+		"monthly": {"expires_date": "2020-02-16T00:00:00Z", "product_identifier": "monthly", "purchase_date": "2020-01-16T00:00:00Z"}
+	}`
+	var ents map[string]Entitlement
+	if err := json.Unmarshal([]byte(data), &ents); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !ents["lifetime"].IsLifetime() {
+		t.Errorf("expected lifetime entitlement, got: %+v", ents["lifetime"])
+	}
+	if ents["monthly"].IsLifetime() || !ents["monthly"].ExpiresDate.Equal(staticTime(t, "2020-02-16 00:00:00")) {
+		t.Errorf("unexpected monthly entitlement: %+v", ents["monthly"])
+	}
+	sub := Subscriber{Entitlements: ents}
+	if !sub.IsEntitledTo("lifetime") {
+		t.Error("expected lifetime entitlement to be active")
+	}
+}
+
+func TestGetSubscriberRequestDate(t *testing.T) {
+	cl := newMockClient(t, 200, map[string]interface{}{
+		"request_date":    "2020-01-16T12:00:00Z",
+		"request_date_ms": 1579176000000,
+		"subscriber": map[string]interface{}{
+			"entitlements": map[string]interface{}{
+				"premium": map[string]interface{}{"expires_date": "2020-01-17T00:00:00Z"},
+			},
+		},
+	}, nil)
+	rc := New("apikey")
+	rc.http = cl
+
+	sub, err := rc.GetSubscriber("123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !sub.RequestDate.Equal(staticTime(t, "2020-01-16 12:00:00")) {
+		t.Errorf("unexpected request date: %v", sub.RequestDate)
+	}
+	if !sub.IsEntitledToAt("premium", sub.RequestDate) {
+		t.Error("expected entitlement to be active at the request date")
+	}
+	if sub.IsEntitledToAt("premium", staticTime(t, "2020-01-17 00:00:01")) {
+		t.Error("expected entitlement to be expired after its expiry")
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	now := staticTime(t, "2020-01-16 12:00:00")
+	cl, count := newCountingClient(t)
+	rc := New("apikey", WithClock(fixedClock(now)))
+	rc.http = cl
+
+	if !rc.Now().Equal(now) {
+		t.Errorf("expected %v, got %v", now, rc.Now())
+	}
+	cached := NewCachedClient(rc, time.Minute, 10)
+	cached.GetSubscriber("123")
+	cached.GetSubscriber("123")
+	if *count != 1 {
+		t.Errorf("expected cache to use the client clock, got %d requests", *count)
+	}
+}
+
+func querySubscriber(t *testing.T) Subscriber {
+	t.Helper()
+	return Subscriber{
+		Entitlements: map[string]Entitlement{
+			"pro":     {ExpiresDate: timePtr(staticTime(t, "2020-02-01 00:00:00")), ProductIdentifier: "monthly"},
+			"premium": {ExpiresDate: timePtr(staticTime(t, "2020-03-01 00:00:00")), ProductIdentifier: "yearly"},
+			"old":     {ExpiresDate: timePtr(staticTime(t, "2019-01-01 00:00:00")), ProductIdentifier: "legacy"},
+		},
+		Subscriptions: map[string]Subscription{
+			"monthly": {ExpiresDate: timePtr(staticTime(t, "2020-02-01 00:00:00")), Store: AppStore},
+			"yearly":  {ExpiresDate: timePtr(staticTime(t, "2020-03-01 00:00:00")), Store: PlayStore, IsSandbox: true},
+			"legacy":  {ExpiresDate: timePtr(staticTime(t, "2019-01-01 00:00:00")), Store: AppStore},
+		},
+	}
+}
+
+func TestSubscriberActiveEntitlements(t *testing.T) {
+	sub := querySubscriber(t)
+	now := staticTime(t, "2020-01-15 00:00:00")
+
+	active := sub.ActiveEntitlements(now)
+	if len(active) != 2 || active[0] != "premium" || active[1] != "pro" {
+		t.Errorf("expected [premium pro], got %v", active)
+	}
+	for id := range sub.Entitlements {
+		if sub.IsEntitledToAt(id, now) != (id == "premium" || id == "pro") {
+			t.Errorf("expected ActiveEntitlements to match IsEntitledToAt for %q", id)
+		}
+	}
+	if active := sub.ActiveEntitlements(staticTime(t, "2021-01-01 00:00:00")); len(active) != 0 {
+		t.Errorf("expected no active entitlements, got %v", active)
+	}
+}
+
+func TestSubscriberActiveSubscriptions(t *testing.T) {
+	sub := querySubscriber(t)
+	now := staticTime(t, "2020-01-15 00:00:00")
+
+	tests := []struct {
+		name     string
+		filter   *SubscriptionFilter
+		expected []string
+	}{
+		{"all", nil, []string{"monthly", "yearly"}},
+		{"store", &SubscriptionFilter{Stores: []Store{PlayStore}}, []string{"yearly"}},
+		{"skip sandbox", &SubscriptionFilter{SkipSandbox: true}, []string{"monthly"}},
+		{"no match", &SubscriptionFilter{Stores: []Store{StripeStore}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs := sub.ActiveSubscriptions(now, tt.filter)
+			if len(subs) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, subs)
+			}
+			for _, id := range tt.expected {
+				if _, ok := subs[id]; !ok {
+					t.Errorf("expected %q in %v", id, subs)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriberHasAnyActive(t *testing.T) {
+	sub := querySubscriber(t)
+	if !sub.HasAnyActive(staticTime(t, "2020-01-15 00:00:00")) {
+		t.Error("expected an active entitlement")
+	}
+	if sub.HasAnyActive(staticTime(t, "2021-01-01 00:00:00")) {
+		t.Error("expected nothing to be active")
+	}
+	lifetime := Subscriber{Entitlements: map[string]Entitlement{"premium": {}}}
+	if !lifetime.HasAnyActive(staticTime(t, "2100-01-01 00:00:00")) {
+		t.Error("expected lifetime entitlement to be active")
+	}
+}
+
+func TestSubscriberLatestExpiration(t *testing.T) {
+	expires, ok := querySubscriber(t).LatestExpiration()
+	if !ok || expires == nil || !expires.Equal(staticTime(t, "2020-03-01 00:00:00")) {
+		t.Errorf("unexpected latest expiration: %v, %v", expires, ok)
+	}
+
+	if _, ok := (Subscriber{}).LatestExpiration(); ok {
+		t.Error("expected no expiration without entitlements")
+	}
+
+	sub := querySubscriber(t)
+	sub.Entitlements["lifetime"] = Entitlement{}
+	if expires, ok := sub.LatestExpiration(); !ok || expires != nil {
+		t.Errorf("expected lifetime, got %v, %v", expires, ok)
+	}
+}
+
+func TestSubscriberSubscriptionFor(t *testing.T) {
+	sub := querySubscriber(t)
+	sub.Entitlements["coins"] = Entitlement{ProductIdentifier: "coin_pack"}
+
+	if s, ok := sub.SubscriptionFor("premium"); !ok || s.Store != PlayStore {
+		t.Errorf("expected yearly subscription, got %+v, %v", s, ok)
+	}
+	if _, ok := sub.SubscriptionFor("coins"); ok {
+		t.Error("expected no subscription for a non-subscription purchase")
+	}
+	if _, ok := sub.SubscriptionFor("missing"); ok {
+		t.Error("expected no subscription for a missing entitlement")
+	}
+}
+
+func fixture(t *testing.T, name string) json.RawMessage {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("error reading fixture: %v", err)
+	}
+	return data
+}
+
+func TestGetSubscriberDecodesFixture(t *testing.T) {
+	rc := New("apikey")
+	rc.http = newMockClient(t, 200, fixture(t, "subscriber.json"), nil)
+
+	sub, err := rc.GetSubscriber("user_123")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	if sub.ManagementURL == nil || *sub.ManagementURL != "https://apps.apple.com/account/subscriptions" {
+		t.Errorf("unexpected management URL: %v", sub.ManagementURL)
+	}
+	if sub.OriginalPurchaseDate == nil || !sub.OriginalPurchaseDate.Equal(staticTime(t, "2020-01-01 00:00:00")) {
+		t.Errorf("unexpected original purchase date: %v", sub.OriginalPurchaseDate)
+	}
+	if p, ok := sub.OtherPurchases["coin_pack"]; !ok || !p.PurchaseDate.Equal(staticTime(t, "2020-01-10 08:30:00")) {
+		t.Errorf("unexpected other purchases: %+v", sub.OtherPurchases)
+	}
+	if !sub.RequestDate.Equal(staticTime(t, "2020-02-16 12:00:00")) {
+		t.Errorf("unexpected request date: %v", sub.RequestDate)
+	}
+
+	if !sub.Entitlements["pro"].IsSandbox || sub.Entitlements["coins"].IsSandbox {
+		t.Errorf("unexpected entitlement sandbox flags: %+v", sub.Entitlements)
+	}
+	if !sub.Entitlements["coins"].IsLifetime() {
+		t.Error("expected coins to be a lifetime entitlement")
+	}
+
+	monthly := sub.Subscriptions["monthly"]
+	if monthly.DisplayName == nil || *monthly.DisplayName != "Pro Monthly" {
+		t.Errorf("unexpected display name: %v", monthly.DisplayName)
+	}
+	if monthly.Price == nil || *monthly.Price != (Price{Amount: 4.99, Currency: "USD"}) {
+		t.Errorf("unexpected price: %+v", monthly.Price)
+	}
+	legacy := sub.Subscriptions["legacy"]
+	if legacy.DisplayName != nil || legacy.Price != nil {
+		t.Errorf("expected null display name and price, got %v, %+v", legacy.DisplayName, legacy.Price)
+	}
+	if legacy.Store != PlayStore || legacy.PeriodType != TrialPeriodType || legacy.UnsubscribeDetectedAt == nil {
+		t.Errorf("unexpected legacy subscription: %+v", legacy)
+	}
+
+	coins := sub.NonSubscriptions["coin_pack"]
+	if len(coins) != 1 || coins[0].StoreTransactionID != "1000000600000001" || coins[0].ID != "cadba0c81b" {
+		t.Errorf("unexpected non-subscriptions: %+v", coins)
+	}
+}
+
+func TestSubscriberCloneOtherPurchases(t *testing.T) {
+	sub := Subscriber{OtherPurchases: map[string]OtherPurchase{"coin_pack": {}}}
+	c := sub.Clone()
+	delete(c.OtherPurchases, "coin_pack")
+	if len(sub.OtherPurchases) != 1 {
+		t.Error("expected clone to copy OtherPurchases")
+	}
+}
+
+func TestEntitlementExpiresAfter(t *testing.T) {
+	earlier := timePtr(staticTime(t, "2020-01-01 00:00:00"))
+	later := timePtr(staticTime(t, "2020-02-01 00:00:00"))
+
+	tests := []struct {
+		name     string
+		expires  *time.Time
+		other    *time.Time
+		expected bool
+	}{
+		{"later", later, earlier, true},
+		{"earlier", earlier, later, false},
+		{"equal", earlier, earlier, false},
+		{"lifetime", nil, later, true},
+		{"against lifetime", later, nil, false},
+		{"both lifetime", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := (Entitlement{ExpiresDate: tt.expires}).ExpiresAfter(tt.other); res != tt.expected {
+				t.Errorf("got %v, expected %v", res, tt.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,18 @@ package revenuecat
 
 import "time"
 
+// Clock tells the time. It lets tests control the time the Client uses for retries, circuit breaking
+// and caching. Rate limiting always uses the real time, since it waits with real sleeps.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
 // Duration holds a predefined entitlement duration.
 type Duration string
 
@@ -17,12 +29,12 @@ const (
 	Lifetime   Duration = "lifetime"
 )
 
-// toMilliseconds takes a time and returns Unix epoch in milliseconds.
-func toMilliseconds(t time.Time) int64 {
+// ToMilliseconds takes a time and returns Unix epoch in milliseconds.
+func ToMilliseconds(t time.Time) int64 {
 	return t.UTC().UnixNano() / 1e6
 }
 
-// fromMilliseconds takes a Unix epoch in milliseconds value and returns a time.Time.
-func fromMilliseconds(t int64) time.Time {
+// FromMilliseconds takes a Unix epoch in milliseconds value and returns a time.Time.
+func FromMilliseconds(t int64) time.Time {
 	return time.Unix(0, t*1e6)
 }
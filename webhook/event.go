@@ -0,0 +1,248 @@
+// Package webhook receives RevenueCat webhook events.
+// https://docs.revenuecat.com/docs/webhooks
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// EventType holds the predefined values for a webhook event type.
+type EventType string
+
+// https://docs.revenuecat.com/docs/event-types-and-fields
+const (
+	Test                 EventType = "TEST"
+	InitialPurchase      EventType = "INITIAL_PURCHASE"
+	Renewal              EventType = "RENEWAL"
+	Cancellation         EventType = "CANCELLATION"
+	Uncancellation       EventType = "UNCANCELLATION"
+	NonRenewingPurchase  EventType = "NON_RENEWING_PURCHASE"
+	SubscriptionPaused   EventType = "SUBSCRIPTION_PAUSED"
+	SubscriptionExtended EventType = "SUBSCRIPTION_EXTENDED"
+	Expiration           EventType = "EXPIRATION"
+	BillingIssue         EventType = "BILLING_ISSUE"
+	ProductChange        EventType = "PRODUCT_CHANGE"
+	Transfer             EventType = "TRANSFER"
+	SubscriberAlias      EventType = "SUBSCRIBER_ALIAS"
+)
+
+// Environment holds the predefined values for the store environment of an event.
+type Environment string
+
+const (
+	Sandbox    Environment = "SANDBOX"
+	Production Environment = "PRODUCTION"
+)
+
+// Reason holds the predefined values for why a subscription was cancelled or expired.
+type Reason string
+
+// https://docs.revenuecat.com/docs/event-types-and-fields#cancellation-and-expiration-reasons
+const (
+	Unsubscribe        Reason = "UNSUBSCRIBE"
+	BillingError       Reason = "BILLING_ERROR"
+	DeveloperInitiated Reason = "DEVELOPER_INITIATED"
+	PriceIncrease      Reason = "PRICE_INCREASE"
+	CustomerSupport    Reason = "CUSTOMER_SUPPORT"
+	UnknownReason      Reason = "UNKNOWN"
+)
+
+// Event holds a webhook event sent by RevenueCat.
+// Store and PeriodType are normalized to the values used by the REST API, so they can be compared with
+// the revenuecat package constants.
+type Event struct {
+	ID                       string
+	Type                     EventType
+	AppID                    string
+	EventTimestamp           time.Time
+	AppUserID                string
+	OriginalAppUserID        string
+	Aliases                  []string
+	ProductID                string
+	NewProductID             string
+	EntitlementIDs           []string
+	PresentedOfferingID      string
+	PeriodType               revenuecat.PeriodType
+	PurchasedAt              time.Time
+	ExpirationAt             *time.Time
+	GracePeriodExpirationAt  *time.Time
+	AutoResumeAt             *time.Time
+	Environment              Environment
+	Store                    revenuecat.Store
+	IsTrialConversion        bool
+	IsFamilyShare            bool
+	CancelReason             Reason
+	ExpirationReason         Reason
+	TransactionID            string
+	OriginalTransactionID    string
+	Price                    float64
+	Currency                 string
+	PriceInPurchasedCurrency float64
+	TakehomePercentage       float64
+	TaxPercentage            float64
+	CommissionPercentage     float64
+	CountryCode              string
+	OfferCode                string
+	SubscriberAttributes     map[string]revenuecat.SubscriberAttribute
+	TransferredFrom          []string
+	TransferredTo            []string
+}
+
+// jsonEvent is the wire format of an Event.
+type jsonEvent struct {
+	ID                        string                                    `json:"id"`
+	Type                      EventType                                 `json:"type"`
+	AppID                     string                                    `json:"app_id,omitempty"`
+	EventTimestampMs          int64                                     `json:"event_timestamp_ms"`
+	AppUserID                 string                                    `json:"app_user_id,omitempty"`
+	OriginalAppUserID         string                                    `json:"original_app_user_id,omitempty"`
+	Aliases                   []string                                  `json:"aliases,omitempty"`
+	ProductID                 string                                    `json:"product_id,omitempty"`
+	NewProductID              string                                    `json:"new_product_id,omitempty"`
+	EntitlementID             *string                                   `json:"entitlement_id,omitempty"`
+	EntitlementIDs            []string                                  `json:"entitlement_ids,omitempty"`
+	PresentedOfferingID       string                                    `json:"presented_offering_id,omitempty"`
+	PeriodType                string                                    `json:"period_type,omitempty"`
+	PurchasedAtMs             int64                                     `json:"purchased_at_ms,omitempty"`
+	ExpirationAtMs            *int64                                    `json:"expiration_at_ms,omitempty"`
+	GracePeriodExpirationAtMs *int64                                    `json:"grace_period_expiration_at_ms,omitempty"`
+	AutoResumeAtMs            *int64                                    `json:"auto_resume_at_ms,omitempty"`
+	Environment               Environment                               `json:"environment,omitempty"`
+	Store                     string                                    `json:"store,omitempty"`
+	IsTrialConversion         bool                                      `json:"is_trial_conversion,omitempty"`
+	IsFamilyShare             bool                                      `json:"is_family_share,omitempty"`
+	CancelReason              Reason                                    `json:"cancel_reason,omitempty"`
+	ExpirationReason          Reason                                    `json:"expiration_reason,omitempty"`
+	TransactionID             string                                    `json:"transaction_id,omitempty"`
+	OriginalTransactionID     string                                    `json:"original_transaction_id,omitempty"`
+	Price                     float64                                   `json:"price,omitempty"`
+	Currency                  string                                    `json:"currency,omitempty"`
+	PriceInPurchasedCurrency  float64                                   `json:"price_in_purchased_currency,omitempty"`
+	TakehomePercentage        float64                                   `json:"takehome_percentage,omitempty"`
+	TaxPercentage             float64                                   `json:"tax_percentage,omitempty"`
+	CommissionPercentage      float64                                   `json:"commission_percentage,omitempty"`
+	CountryCode               string                                    `json:"country_code,omitempty"`
+	OfferCode                 string                                    `json:"offer_code,omitempty"`
+	SubscriberAttributes      map[string]revenuecat.SubscriberAttribute `json:"subscriber_attributes,omitempty"`
+	TransferredFrom           []string                                  `json:"transferred_from,omitempty"`
+	TransferredTo             []string                                  `json:"transferred_to,omitempty"`
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var j jsonEvent
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*e = Event{
+		ID:                       j.ID,
+		Type:                     j.Type,
+		AppID:                    j.AppID,
+		EventTimestamp:           revenuecat.FromMilliseconds(j.EventTimestampMs),
+		AppUserID:                j.AppUserID,
+		OriginalAppUserID:        j.OriginalAppUserID,
+		Aliases:                  j.Aliases,
+		ProductID:                j.ProductID,
+		NewProductID:             j.NewProductID,
+		EntitlementIDs:           j.EntitlementIDs,
+		PresentedOfferingID:      j.PresentedOfferingID,
+		PeriodType:               revenuecat.PeriodType(strings.ToLower(j.PeriodType)),
+		ExpirationAt:             fromMillisecondsPtr(j.ExpirationAtMs),
+		GracePeriodExpirationAt:  fromMillisecondsPtr(j.GracePeriodExpirationAtMs),
+		AutoResumeAt:             fromMillisecondsPtr(j.AutoResumeAtMs),
+		Environment:              j.Environment,
+		Store:                    revenuecat.Store(strings.ToLower(j.Store)),
+		IsTrialConversion:        j.IsTrialConversion,
+		IsFamilyShare:            j.IsFamilyShare,
+		CancelReason:             j.CancelReason,
+		ExpirationReason:         j.ExpirationReason,
+		TransactionID:            j.TransactionID,
+		OriginalTransactionID:    j.OriginalTransactionID,
+		Price:                    j.Price,
+		Currency:                 j.Currency,
+		PriceInPurchasedCurrency: j.PriceInPurchasedCurrency,
+		TakehomePercentage:       j.TakehomePercentage,
+		TaxPercentage:            j.TaxPercentage,
+		CommissionPercentage:     j.CommissionPercentage,
+		CountryCode:              j.CountryCode,
+		OfferCode:                j.OfferCode,
+		SubscriberAttributes:     j.SubscriberAttributes,
+		TransferredFrom:          j.TransferredFrom,
+		TransferredTo:            j.TransferredTo,
+	}
+	if j.PurchasedAtMs > 0 {
+		e.PurchasedAt = revenuecat.FromMilliseconds(j.PurchasedAtMs)
+	}
+	// Older events only carry the deprecated single entitlement_id.
+	if len(e.EntitlementIDs) == 0 && j.EntitlementID != nil {
+		e.EntitlementIDs = []string{*j.EntitlementID}
+	}
+	return nil
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	j := jsonEvent{
+		ID:                        e.ID,
+		Type:                      e.Type,
+		AppID:                     e.AppID,
+		EventTimestampMs:          revenuecat.ToMilliseconds(e.EventTimestamp),
+		AppUserID:                 e.AppUserID,
+		OriginalAppUserID:         e.OriginalAppUserID,
+		Aliases:                   e.Aliases,
+		ProductID:                 e.ProductID,
+		NewProductID:              e.NewProductID,
+		EntitlementIDs:            e.EntitlementIDs,
+		PresentedOfferingID:       e.PresentedOfferingID,
+		PeriodType:                strings.ToUpper(string(e.PeriodType)),
+		ExpirationAtMs:            toMillisecondsPtr(e.ExpirationAt),
+		GracePeriodExpirationAtMs: toMillisecondsPtr(e.GracePeriodExpirationAt),
+		AutoResumeAtMs:            toMillisecondsPtr(e.AutoResumeAt),
+		Environment:               e.Environment,
+		Store:                     strings.ToUpper(string(e.Store)),
+		IsTrialConversion:         e.IsTrialConversion,
+		IsFamilyShare:             e.IsFamilyShare,
+		CancelReason:              e.CancelReason,
+		ExpirationReason:          e.ExpirationReason,
+		TransactionID:             e.TransactionID,
+		OriginalTransactionID:     e.OriginalTransactionID,
+		Price:                     e.Price,
+		Currency:                  e.Currency,
+		PriceInPurchasedCurrency:  e.PriceInPurchasedCurrency,
+		TakehomePercentage:        e.TakehomePercentage,
+		TaxPercentage:             e.TaxPercentage,
+		CommissionPercentage:      e.CommissionPercentage,
+		CountryCode:               e.CountryCode,
+		OfferCode:                 e.OfferCode,
+		SubscriberAttributes:      e.SubscriberAttributes,
+		TransferredFrom:           e.TransferredFrom,
+		TransferredTo:             e.TransferredTo,
+	}
+	if !e.PurchasedAt.IsZero() {
+		j.PurchasedAtMs = revenuecat.ToMilliseconds(e.PurchasedAt)
+	}
+	return json.Marshal(j)
+}
+
+// IsSandbox returns true if the event was produced by a sandbox purchase.
+func (e Event) IsSandbox() bool {
+	return e.Environment == Sandbox
+}
+
+func fromMillisecondsPtr(ms *int64) *time.Time {
+	if ms == nil {
+		return nil
+	}
+	t := revenuecat.FromMilliseconds(*ms)
+	return &t
+}
+
+func toMillisecondsPtr(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := revenuecat.ToMilliseconds(*t)
+	return &ms
+}
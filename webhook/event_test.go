@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func TestEventUnmarshalJSON(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/initial_purchase.json")
+	if err != nil {
+		t.Fatalf("error reading fixture: %v", err)
+	}
+	var payload struct {
+		Event Event `json:"event"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	expiration := time.Unix(0, 1581810857000*1e6)
+	expected := Event{
+		ID:                       "CDFD7A8A-9E41-4DB4-8BE7-63EBA7B2A2A6",
+		Type:                     InitialPurchase,
+		AppID:                    "1234567890",
+		EventTimestamp:           time.Unix(0, 1579132457123*1e6),
+		AppUserID:                "user_123",
+		OriginalAppUserID:        "$RCAnonymousID:8069238d6049ce87cc529853916d624c",
+		Aliases:                  []string{"$RCAnonymousID:8069238d6049ce87cc529853916d624c", "user_123"},
+		ProductID:                "monthly_premium",
+		EntitlementIDs:           []string{"premium"},
+		PresentedOfferingID:      "default",
+		PeriodType:               revenuecat.NormalPeriodType,
+		PurchasedAt:              time.Unix(0, 1579132457000*1e6),
+		ExpirationAt:             &expiration,
+		Environment:              Production,
+		Store:                    revenuecat.AppStore,
+		TransactionID:            "1000000000000001",
+		OriginalTransactionID:    "1000000000000001",
+		Price:                    9.99,
+		Currency:                 "USD",
+		PriceInPurchasedCurrency: 9.99,
+		TakehomePercentage:       0.7,
+		CommissionPercentage:     0.3,
+		CountryCode:              "US",
+		SubscriberAttributes: map[string]revenuecat.SubscriberAttribute{
+			"$email": {Value: "user@example.com", UpdatedAt: time.Unix(0, 1579132400000*1e6)},
+		},
+	}
+	if !reflect.DeepEqual(payload.Event, expected) {
+		t.Errorf("expected: %+v\n, actual: %+v", expected, payload.Event)
+	}
+}
+
+func TestEventUnmarshalJSONLegacyEntitlementID(t *testing.T) {
+	var e Event
+	err := json.Unmarshal([]byte(`{"type":"RENEWAL","entitlement_id":"pro","period_type":"TRIAL","store":"PLAY_STORE","environment":"SANDBOX"}`), &e)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !reflect.DeepEqual(e.EntitlementIDs, []string{"pro"}) {
+		t.Errorf("expected entitlement IDs [pro], got: %v", e.EntitlementIDs)
+	}
+	if e.PeriodType != revenuecat.TrialPeriodType || e.Store != revenuecat.PlayStore || !e.IsSandbox() {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e.ExpirationAt != nil || !e.PurchasedAt.IsZero() {
+		t.Errorf("expected no dates, got: %+v", e)
+	}
+}
+
+func TestEventMarshalJSON(t *testing.T) {
+	expiration := time.Unix(0, 1581810857000*1e6)
+	e := Event{
+		ID:             "evt_1",
+		Type:           Renewal,
+		EventTimestamp: time.Unix(0, 1579132457123*1e6),
+		AppUserID:      "user_123",
+		ProductID:      "monthly",
+		EntitlementIDs: []string{"premium"},
+		PeriodType:     revenuecat.TrialPeriodType,
+		PurchasedAt:    time.Unix(0, 1579132457000*1e6),
+		ExpirationAt:   &expiration,
+		Environment:    Sandbox,
+		Store:          revenuecat.PlayStore,
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	expected := `{"id":"evt_1","type":"RENEWAL","event_timestamp_ms":1579132457123,"app_user_id":"user_123","product_id":"monthly","entitlement_ids":["premium"],"period_type":"TRIAL","purchased_at_ms":1579132457000,"expiration_at_ms":1581810857000,"environment":"SANDBOX","store":"PLAY_STORE"}`
+	if string(b) != expected {
+		t.Errorf("expected: %s\n, actual: %s", expected, b)
+	}
+
+	var res Event
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if !reflect.DeepEqual(res, e) {
+		t.Errorf("expected round trip: %+v\n, actual: %+v", e, res)
+	}
+}
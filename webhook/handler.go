@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxBodySize limits the size of a webhook request body.
+const maxBodySize = 1 << 20
+
+// Func handles a single webhook event. Returning an error makes the Handler respond with a 500,
+// so RevenueCat retries the delivery later.
+type Func func(ctx context.Context, event Event) error
+
+// Handler is an http.Handler that receives RevenueCat webhooks and dispatches them to callbacks by event type.
+type Handler struct {
+	authorization string
+	noAuth        bool
+	callbacks     map[EventType]Func
+	fallback      Func
+	seen          SeenStore
+	onError       func(ctx context.Context, event Event, err error)
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+type Option func(*Handler)
+
+// NewHandler returns a new *Handler that only accepts requests whose Authorization header equals authorization,
+// as configured in the RevenueCat dashboard. It panics if authorization is empty, unless WithoutAuthorization is set,
+// so a missing secret can't silently accept forged events.
+func NewHandler(authorization string, opts ...Option) *Handler {
+	h := &Handler{
+		authorization: authorization,
+		callbacks:     make(map[EventType]Func),
+		inFlight:      make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.authorization == "" && !h.noAuth {
+		panic("webhook: NewHandler called with an empty authorization")
+	}
+	return h
+}
+
+// WithoutAuthorization - Option to accept requests without checking the Authorization header
+func WithoutAuthorization() Option {
+	return func(h *Handler) {
+		h.noAuth = true
+	}
+}
+
+// WithCallback - Option to set the callback for an event type
+func WithCallback(eventType EventType, fn Func) Option {
+	return func(h *Handler) {
+		h.callbacks[eventType] = fn
+	}
+}
+
+// WithDefaultCallback - Option to set the callback for event types without their own callback
+func WithDefaultCallback(fn Func) Option {
+	return func(h *Handler) {
+		h.fallback = fn
+	}
+}
+
+// WithSeenStore - Option to skip events that the store has already seen processed
+func WithSeenStore(store SeenStore) Option {
+	return func(h *Handler) {
+		h.seen = store
+	}
+}
+
+// WithErrorHandler - Option to report errors that don't change the response, such as failing to mark an event processed
+func WithErrorHandler(fn func(ctx context.Context, event Event, err error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		APIVersion string `json:"api_version"`
+		Event      Event  `json:"event"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&payload); err != nil {
+		http.Error(w, "invalid event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, msg := h.process(r.Context(), payload.Event)
+	if status != http.StatusOK {
+		http.Error(w, msg, status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// process dispatches event unless the SeenStore has already seen it, and marks it processed once
+// the callback succeeds. It returns the HTTP status to respond with.
+func (h *Handler) process(ctx context.Context, event Event) (int, string) {
+	if h.seen == nil || event.ID == "" {
+		if err := h.dispatch(ctx, event); err != nil {
+			return http.StatusInternalServerError, "error handling event"
+		}
+		return http.StatusOK, ""
+	}
+
+	// Reject concurrent deliveries of the same event, RevenueCat will retry them later.
+	if !h.acquire(event.ID) {
+		return http.StatusConflict, "event is already being processed"
+	}
+	defer h.release(event.ID)
+
+	seen, err := h.seen.Seen(ctx, event.ID)
+	if err != nil {
+		return http.StatusInternalServerError, "error checking event"
+	}
+	if seen {
+		return http.StatusOK, ""
+	}
+	if err := h.dispatch(ctx, event); err != nil {
+		return http.StatusInternalServerError, "error handling event"
+	}
+	// The callback has succeeded, so acknowledge the event even if it can't be marked. The worst
+	// case is that a redelivery is processed again, which is still at-least-once.
+	if err := h.seen.MarkProcessed(ctx, event.ID); err != nil && h.onError != nil {
+		h.onError(ctx, event, err)
+	}
+	return http.StatusOK, ""
+}
+
+func (h *Handler) acquire(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.inFlight[id]; ok {
+		return false
+	}
+	h.inFlight[id] = struct{}{}
+	return true
+}
+
+func (h *Handler) release(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inFlight, id)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.noAuth {
+		return true
+	}
+	got := r.Header.Get("Authorization")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.authorization)) == 1
+}
+
+// dispatch sends event to its callback. Events without a callback are acknowledged and dropped.
+func (h *Handler) dispatch(ctx context.Context, event Event) error {
+	fn, ok := h.callbacks[event.Type]
+	if !ok {
+		fn = h.fallback
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(ctx, event)
+}
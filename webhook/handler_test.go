@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRequest(t *testing.T, authorization, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/webhooks/revenuecat", strings.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req
+}
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("error reading fixture: %v", err)
+	}
+	return string(data)
+}
+
+func TestHandlerDispatch(t *testing.T) {
+	var got []Event
+	var fallback []Event
+	h := NewHandler("Bearer secret",
+		WithCallback(InitialPurchase, func(ctx context.Context, e Event) error {
+			got = append(got, e)
+			return nil
+		}),
+		WithDefaultCallback(func(ctx context.Context, e Event) error {
+			fallback = append(fallback, e)
+			return nil
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, "Bearer secret", fixture(t, "initial_purchase.json")))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, "Bearer secret", `{"event":{"id":"2","type":"RENEWAL"}}`))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got: %d", rec.Code)
+	}
+
+	if len(got) != 1 || got[0].AppUserID != "user_123" {
+		t.Errorf("expected one INITIAL_PURCHASE event, got: %+v", got)
+	}
+	if len(fallback) != 1 || fallback[0].Type != Renewal {
+		t.Errorf("expected one RENEWAL event, got: %+v", fallback)
+	}
+}
+
+func TestHandlerResponses(t *testing.T) {
+	h := NewHandler("Bearer secret",
+		WithCallback(Expiration, func(ctx context.Context, e Event) error {
+			return errors.New("database unavailable")
+		}),
+	)
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		body          string
+		expected      int
+	}{{
+		name:          "unhandled type",
+		authorization: "Bearer secret",
+		body:          `{"event":{"id":"1","type":"TEST"}}`,
+		expected:      http.StatusOK,
+	}, {
+		name:     "missing authorization",
+		body:     `{"event":{"id":"1","type":"TEST"}}`,
+		expected: http.StatusUnauthorized,
+	}, {
+		name:          "wrong authorization",
+		authorization: "Bearer wrong",
+		body:          `{"event":{"id":"1","type":"TEST"}}`,
+		expected:      http.StatusUnauthorized,
+	}, {
+		name:          "invalid json",
+		authorization: "Bearer secret",
+		body:          `{"event":`,
+		expected:      http.StatusBadRequest,
+	}, {
+		name:          "callback error",
+		authorization: "Bearer secret",
+		body:          `{"event":{"id":"1","type":"EXPIRATION"}}`,
+		expected:      http.StatusInternalServerError,
+	}, {
+		name:          "wrong method",
+		method:        "GET",
+		authorization: "Bearer secret",
+		expected:      http.StatusMethodNotAllowed,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := newRequest(t, test.authorization, test.body)
+			if test.method != "" {
+				req.Method = test.method
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != test.expected {
+				t.Errorf("expected status %d, got: %d", test.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewHandlerEmptyAuthorization(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected NewHandler to panic with an empty authorization")
+			}
+		}()
+		NewHandler("")
+	}()
+
+	h := NewHandler("", WithoutAuthorization())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, "", `{"event":{"id":"1","type":"TEST"}}`))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got: %d", rec.Code)
+	}
+}
+
+func TestHandlerDeduplicates(t *testing.T) {
+	calls := 0
+	fail := true
+	h := NewHandler("", WithoutAuthorization(),
+		WithSeenStore(NewMemorySeenStore(time.Hour)),
+		WithDefaultCallback(func(ctx context.Context, e Event) error {
+			calls++
+			if fail {
+				return errors.New("temporary failure")
+			}
+			return nil
+		}),
+	)
+	body := `{"event":{"id":"evt_1","type":"RENEWAL"}}`
+
+	expected := []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK}
+	for i, status := range expected {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newRequest(t, "", body))
+		if rec.Code != status {
+			t.Errorf("delivery %d: expected status %d, got: %d", i+1, status, rec.Code)
+		}
+		fail = false
+	}
+	if calls != 2 {
+		t.Errorf("expected callback to run twice, got: %d", calls)
+	}
+}
+
+type failingSeenStore struct{ SeenStore }
+
+func (failingSeenStore) MarkProcessed(ctx context.Context, id string) error {
+	return errors.New("disk full")
+}
+
+func TestHandlerReportsMarkProcessedError(t *testing.T) {
+	var reported []error
+	h := NewHandler("", WithoutAuthorization(),
+		WithSeenStore(failingSeenStore{NewMemorySeenStore(time.Hour)}),
+		WithErrorHandler(func(ctx context.Context, e Event, err error) {
+			if e.ID != "evt_1" {
+				t.Errorf("unexpected event: %+v", e)
+			}
+			reported = append(reported, err)
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, "", `{"event":{"id":"evt_1","type":"RENEWAL"}}`))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got: %d", rec.Code)
+	}
+	if len(reported) != 1 || reported[0].Error() != "disk full" {
+		t.Errorf("expected the MarkProcessed error to be reported, got: %v", reported)
+	}
+}
+
+func TestHandlerRejectsConcurrentDuplicate(t *testing.T) {
+	h := NewHandler("", WithoutAuthorization(), WithSeenStore(NewMemorySeenStore(time.Hour)))
+	h.acquire("evt_1")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, "", `{"event":{"id":"evt_1","type":"RENEWAL"}}`))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got: %d", rec.Code)
+	}
+}
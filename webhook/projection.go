@@ -0,0 +1,295 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+// Projection is a Subscriber maintained locally by applying webhook events, so entitlement checks
+// don't need a call to GetSubscriber.
+type Projection struct {
+	Subscriber revenuecat.Subscriber
+	// Versions holds the timestamp of the latest event applied to each product, to detect out-of-order delivery.
+	Versions map[string]time.Time
+}
+
+// Apply applies event to the projection and reports whether it changed anything. Events older than
+// the latest event already applied to the same product are ignored.
+// TRANSFER events move state between subscribers and are handled by Projector.
+func (p *Projection) Apply(e Event) bool {
+	if e.ProductID == "" {
+		return false
+	}
+	if latest, ok := p.Versions[e.ProductID]; ok && e.EventTimestamp.Before(latest) {
+		return false
+	}
+
+	switch e.Type {
+	case InitialPurchase, Renewal, Uncancellation, SubscriptionExtended:
+		sub := p.subscription(e)
+		sub.UnsubscribeDetectedAt = nil
+		sub.AutoResumeDate = nil
+		if e.Type != Uncancellation {
+			// A successful charge resolves any billing issue.
+			sub.BillingIssuesDetectedAt = nil
+			sub.GracePeriodExpiresDate = nil
+			sub.RefundedAt = nil
+		}
+		p.Subscriber.Subscriptions[e.ProductID] = sub
+		p.setEntitlements(e, nil)
+	case NonRenewingPurchase:
+		p.addNonSubscription(e)
+		p.setEntitlements(e, nil)
+	case Cancellation:
+		sub := p.subscription(e)
+		at := e.EventTimestamp
+		sub.UnsubscribeDetectedAt = &at
+		if e.CancelReason == CustomerSupport {
+			// Refunds revoke access immediately, so the event's expiration is the refund time.
+			sub.RefundedAt = &at
+		}
+		p.Subscriber.Subscriptions[e.ProductID] = sub
+		p.setEntitlements(e, nil)
+	case Expiration:
+		sub := p.subscription(e)
+		p.Subscriber.Subscriptions[e.ProductID] = sub
+		p.setEntitlements(e, nil)
+	case BillingIssue:
+		sub := p.subscription(e)
+		at := e.EventTimestamp
+		sub.BillingIssuesDetectedAt = &at
+		sub.GracePeriodExpiresDate = e.GracePeriodExpirationAt
+		p.Subscriber.Subscriptions[e.ProductID] = sub
+		p.setEntitlements(e, e.GracePeriodExpirationAt)
+	case SubscriptionPaused:
+		sub := p.subscription(e)
+		sub.AutoResumeDate = e.AutoResumeAt
+		p.Subscriber.Subscriptions[e.ProductID] = sub
+	default:
+		return false
+	}
+
+	if e.OriginalAppUserID != "" {
+		p.Subscriber.OriginalAppUserID = e.OriginalAppUserID
+	}
+	p.mergeAttributes(e.SubscriberAttributes)
+	p.Versions[e.ProductID] = e.EventTimestamp
+	return true
+}
+
+// subscription returns the current Subscription for the event's product, updated with the event's purchase details.
+func (p *Projection) subscription(e Event) revenuecat.Subscription {
+	p.init()
+	sub := p.Subscriber.Subscriptions[e.ProductID]
+	if !e.PurchasedAt.IsZero() {
+		sub.PurchaseDate = e.PurchasedAt
+		if sub.OriginalPurchaseDate.IsZero() {
+			sub.OriginalPurchaseDate = e.PurchasedAt
+		}
+	}
+	if e.ExpirationAt != nil {
+		sub.ExpiresDate = e.ExpirationAt
+	}
+	if e.PeriodType != "" {
+		sub.PeriodType = e.PeriodType
+	}
+	if e.Store != "" {
+		sub.Store = e.Store
+	}
+	if e.TransactionID != "" {
+		sub.StoreTransactionID = e.TransactionID
+	}
+	sub.IsSandbox = e.IsSandbox()
+	if e.IsFamilyShare {
+		sub.OwnershipType = revenuecat.FamilySharedOwnershipType
+	} else {
+		sub.OwnershipType = revenuecat.PurchasedOwnershipType
+	}
+	return sub
+}
+
+func (p *Projection) addNonSubscription(e Event) {
+	p.init()
+	purchases := p.Subscriber.NonSubscriptions[e.ProductID]
+	for _, ns := range purchases {
+		if ns.ID == e.TransactionID {
+			return
+		}
+	}
+	p.Subscriber.NonSubscriptions[e.ProductID] = append(purchases, revenuecat.NonSubscription{
+		ID:           e.TransactionID,
+		PurchaseDate: e.PurchasedAt,
+		Store:        e.Store,
+		IsSandbox:    e.IsSandbox(),
+	})
+}
+
+// setEntitlements points the event's entitlements at its product. An entitlement backed by another
+// product that expires later is left alone, as RevenueCat reports the longest-lived product.
+// Purchases without an expiration, such as lifetime purchases, make the entitlements lifetime. Other
+// events without an expiration keep the entitlement's current expiry, and don't add entitlements.
+func (p *Projection) setEntitlements(e Event, gracePeriod *time.Time) {
+	p.init()
+	purchase := e.Type == NonRenewingPurchase || e.Type == InitialPurchase
+	for _, id := range e.EntitlementIDs {
+		ent, ok := p.Subscriber.Entitlements[id]
+		var expires *time.Time
+		switch {
+		case e.ExpirationAt != nil:
+			t := *e.ExpirationAt
+			expires = &t
+		case !purchase:
+			if !ok || ent.ProductIdentifier != e.ProductID {
+				continue
+			}
+			expires = ent.ExpiresDate
+		}
+		if ok && ent.ProductIdentifier != e.ProductID && ent.ExpiresAfter(expires) {
+			continue
+		}
+		ent.ExpiresDate = expires
+		ent.GracePeriodExpiresDate = gracePeriod
+		ent.ProductIdentifier = e.ProductID
+		if !e.PurchasedAt.IsZero() {
+			ent.PurchaseDate = e.PurchasedAt
+		}
+		p.Subscriber.Entitlements[id] = ent
+	}
+}
+
+func (p *Projection) mergeAttributes(attrs map[string]revenuecat.SubscriberAttribute) {
+	p.init()
+	for k, v := range attrs {
+		if cur, ok := p.Subscriber.SubscriberAttributes[k]; ok && cur.UpdatedAt.After(v.UpdatedAt) {
+			continue
+		}
+		p.Subscriber.SubscriberAttributes[k] = v
+	}
+}
+
+func (p *Projection) init() {
+	if p.Versions == nil {
+		p.Versions = make(map[string]time.Time)
+	}
+	if p.Subscriber.Entitlements == nil {
+		p.Subscriber.Entitlements = make(map[string]revenuecat.Entitlement)
+	}
+	if p.Subscriber.Subscriptions == nil {
+		p.Subscriber.Subscriptions = make(map[string]revenuecat.Subscription)
+	}
+	if p.Subscriber.NonSubscriptions == nil {
+		p.Subscriber.NonSubscriptions = make(map[string][]revenuecat.NonSubscription)
+	}
+	if p.Subscriber.SubscriberAttributes == nil {
+		p.Subscriber.SubscriberAttributes = make(map[string]revenuecat.SubscriberAttribute)
+	}
+}
+
+// Projector keeps an in-memory Projection per subscriber. Its Handle method can be used as a Handler callback.
+type Projector struct {
+	mu          sync.RWMutex
+	projections map[string]*Projection
+}
+
+// NewProjector returns a new, empty *Projector.
+func NewProjector() *Projector {
+	return &Projector{
+		projections: make(map[string]*Projection),
+	}
+}
+
+// Handle applies event to the projection of the subscriber it belongs to.
+func (p *Projector) Handle(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if event.Type == Transfer {
+		p.transfer(event)
+		return nil
+	}
+	ids := eventUserIDs(event)
+	if len(ids) == 0 {
+		return nil
+	}
+	p.projection(ids).Apply(event)
+	return nil
+}
+
+// Subscriber returns a copy of the projected Subscriber for userID, which may be any of the subscriber's aliases.
+func (p *Projector) Subscriber(userID string) (revenuecat.Subscriber, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	proj, ok := p.projections[userID]
+	if !ok {
+		return revenuecat.Subscriber{}, false
+	}
+	return proj.Subscriber.Clone(), true
+}
+
+// projection returns the projection for any of ids, creating one if needed, and links all ids to it.
+func (p *Projector) projection(ids []string) *Projection {
+	var proj *Projection
+	for _, id := range ids {
+		if proj = p.projections[id]; proj != nil {
+			break
+		}
+	}
+	if proj == nil {
+		proj = &Projection{}
+		proj.init()
+	}
+	for _, id := range ids {
+		p.projections[id] = proj
+	}
+	return proj
+}
+
+// transfer moves all purchases from the TransferredFrom subscriber to the TransferredTo subscriber.
+func (p *Projector) transfer(e Event) {
+	var from *Projection
+	for _, id := range e.TransferredFrom {
+		if from = p.projections[id]; from != nil {
+			break
+		}
+	}
+	if from == nil || len(e.TransferredTo) == 0 {
+		return
+	}
+	to := p.projection(e.TransferredTo)
+	if to == from {
+		return
+	}
+	for k, v := range from.Subscriber.Subscriptions {
+		to.Subscriber.Subscriptions[k] = v
+	}
+	for k, v := range from.Subscriber.Entitlements {
+		to.Subscriber.Entitlements[k] = v
+	}
+	for k, v := range from.Subscriber.NonSubscriptions {
+		to.Subscriber.NonSubscriptions[k] = append(to.Subscriber.NonSubscriptions[k], v...)
+	}
+	for k, v := range from.Versions {
+		if v.After(to.Versions[k]) {
+			to.Versions[k] = v
+		}
+	}
+	from.Subscriber.Subscriptions = nil
+	from.Subscriber.Entitlements = nil
+	from.Subscriber.NonSubscriptions = nil
+	from.init()
+}
+
+func eventUserIDs(e Event) []string {
+	ids := make([]string, 0, len(e.Aliases)+2)
+	seen := make(map[string]bool)
+	for _, id := range append([]string{e.AppUserID, e.OriginalAppUserID}, e.Aliases...) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
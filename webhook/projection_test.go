@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mhemmings/revenuecat/v2"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func purchaseEvent(typ EventType, at time.Time, expires time.Time) Event {
+	return Event{
+		ID:             string(typ) + at.String(),
+		Type:           typ,
+		AppUserID:      "user_123",
+		ProductID:      "monthly",
+		EntitlementIDs: []string{"premium"},
+		PeriodType:     revenuecat.NormalPeriodType,
+		Store:          revenuecat.AppStore,
+		Environment:    Production,
+		EventTimestamp: at,
+		PurchasedAt:    at,
+		ExpirationAt:   timePtr(expires),
+	}
+}
+
+func TestProjectionLifecycle(t *testing.T) {
+	now := time.Now()
+	var p Projection
+
+	if !p.Apply(purchaseEvent(InitialPurchase, now.Add(-time.Hour), now.Add(time.Hour))) {
+		t.Fatal("expected INITIAL_PURCHASE to apply")
+	}
+	if !p.Subscriber.IsEntitledTo("premium") {
+		t.Error("expected entitlement after purchase")
+	}
+
+	cancel := purchaseEvent(Cancellation, now.Add(-30*time.Minute), now.Add(time.Hour))
+	cancel.CancelReason = Unsubscribe
+	p.Apply(cancel)
+	sub := p.Subscriber.Subscriptions["monthly"]
+	if sub.UnsubscribeDetectedAt == nil || sub.RefundedAt != nil {
+		t.Errorf("expected unsubscribe without refund, got: %+v", sub)
+	}
+	if !p.Subscriber.IsEntitledTo("premium") {
+		t.Error("expected entitlement until the end of the period")
+	}
+
+	p.Apply(purchaseEvent(Expiration, now.Add(-time.Minute), now.Add(-time.Minute)))
+	if p.Subscriber.IsEntitledTo("premium") {
+		t.Error("expected no entitlement after expiration")
+	}
+}
+
+func TestProjectionOutOfOrder(t *testing.T) {
+	now := time.Now()
+	var p Projection
+
+	p.Apply(purchaseEvent(Renewal, now.Add(-time.Minute), now.Add(30*24*time.Hour)))
+	if p.Apply(purchaseEvent(Expiration, now.Add(-time.Hour), now.Add(-time.Hour))) {
+		t.Error("expected stale EXPIRATION to be ignored")
+	}
+	if !p.Subscriber.IsEntitledTo("premium") {
+		t.Error("expected entitlement from the later RENEWAL")
+	}
+}
+
+func TestProjectionBillingIssue(t *testing.T) {
+	now := time.Now()
+	var p Projection
+
+	p.Apply(purchaseEvent(InitialPurchase, now.Add(-time.Hour), now.Add(-time.Minute)))
+	issue := purchaseEvent(BillingIssue, now.Add(-time.Minute), now.Add(-time.Minute))
+	issue.GracePeriodExpirationAt = timePtr(now.Add(time.Hour))
+	p.Apply(issue)
+
+	sub := p.Subscriber.Subscriptions["monthly"]
+	if sub.BillingIssuesDetectedAt == nil || sub.GracePeriodExpiresDate == nil {
+		t.Errorf("expected billing issue and grace period, got: %+v", sub)
+	}
+	if ent := p.Subscriber.Entitlements["premium"]; ent.GracePeriodExpiresDate == nil {
+		t.Errorf("expected entitlement grace period, got: %+v", ent)
+	}
+
+	p.Apply(purchaseEvent(Renewal, now, now.Add(time.Hour)))
+	sub = p.Subscriber.Subscriptions["monthly"]
+	if sub.BillingIssuesDetectedAt != nil || sub.GracePeriodExpiresDate != nil {
+		t.Errorf("expected renewal to clear billing issue, got: %+v", sub)
+	}
+}
+
+func TestProjectionNonRenewingPurchase(t *testing.T) {
+	now := time.Now()
+	var p Projection
+
+	e := purchaseEvent(NonRenewingPurchase, now, now.Add(time.Hour))
+	e.ProductID = "coins"
+	e.TransactionID = "txn_1"
+	p.Apply(e)
+	p.Apply(e)
+
+	if n := len(p.Subscriber.NonSubscriptions["coins"]); n != 1 {
+		t.Errorf("expected 1 non-subscription, got: %d", n)
+	}
+}
+
+func TestProjectionLifetimePurchase(t *testing.T) {
+	now := time.Now()
+	var p Projection
+
+	p.Apply(purchaseEvent(InitialPurchase, now.Add(-time.Hour), now.Add(time.Hour)))
+	e := purchaseEvent(NonRenewingPurchase, now, now)
+	e.ProductID = "lifetime_unlock"
+	e.ExpirationAt = nil
+	p.Apply(e)
+	if ent := p.Subscriber.Entitlements["premium"]; !ent.IsLifetime() || ent.ProductIdentifier != "lifetime_unlock" {
+		t.Errorf("expected lifetime entitlement, got: %+v", ent)
+	}
+
+	// A later event for the subscription doesn't shorten the lifetime entitlement.
+	p.Apply(purchaseEvent(Expiration, now.Add(time.Minute), now.Add(time.Minute)))
+	if !p.Subscriber.IsEntitledTo("premium") {
+		t.Error("expected lifetime entitlement to stay active")
+	}
+}
+
+func TestProjectionMissingExpiration(t *testing.T) {
+	now := time.Now()
+
+	for _, typ := range []EventType{Cancellation, Expiration, BillingIssue, Renewal} {
+		t.Run(string(typ), func(t *testing.T) {
+			var p Projection
+			p.Apply(purchaseEvent(InitialPurchase, now.Add(-2*time.Hour), now.Add(-time.Hour)))
+
+			e := purchaseEvent(typ, now.Add(-time.Minute), now)
+			e.ExpirationAt = nil
+			p.Apply(e)
+			ent := p.Subscriber.Entitlements["premium"]
+			if ent.IsLifetime() || p.Subscriber.IsEntitledTo("premium") {
+				t.Errorf("expected the expired entitlement to stay expired, got: %+v", ent)
+			}
+
+			e.EntitlementIDs = []string{"other"}
+			p.Apply(e)
+			if _, ok := p.Subscriber.Entitlements["other"]; ok {
+				t.Error("expected no entitlement to be added without an expiration")
+			}
+		})
+	}
+}
+
+func TestProjectorAliasesAndTransfer(t *testing.T) {
+	now := time.Now()
+	p := NewProjector()
+	ctx := context.Background()
+
+	e := purchaseEvent(InitialPurchase, now, now.Add(time.Hour))
+	e.OriginalAppUserID = "$RCAnonymousID:abc"
+	e.Aliases = []string{"$RCAnonymousID:abc", "user_123"}
+	p.Handle(ctx, e)
+
+	sub, ok := p.Subscriber("$RCAnonymousID:abc")
+	if !ok || !sub.IsEntitledTo("premium") {
+		t.Error("expected alias to resolve to the entitled subscriber")
+	}
+
+	// Mutating the returned copy must not affect the projection.
+	delete(sub.Entitlements, "premium")
+	if sub, _ := p.Subscriber("user_123"); !sub.IsEntitledTo("premium") {
+		t.Error("expected projection to be unaffected by caller mutation")
+	}
+
+	p.Handle(ctx, Event{
+		Type:            Transfer,
+		EventTimestamp:  now,
+		TransferredFrom: []string{"user_123"},
+		TransferredTo:   []string{"user_456"},
+	})
+	if sub, _ := p.Subscriber("user_123"); sub.IsEntitledTo("premium") {
+		t.Error("expected entitlement to move away from user_123")
+	}
+	if sub, ok := p.Subscriber("user_456"); !ok || !sub.IsEntitledTo("premium") {
+		t.Error("expected entitlement to move to user_456")
+	}
+}
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SeenStore records which events have already been processed, so redelivered events can be skipped.
+type SeenStore interface {
+	// Seen reports whether the event with the given ID has been processed.
+	Seen(ctx context.Context, id string) (bool, error)
+	// MarkProcessed records that the event with the given ID has been processed.
+	MarkProcessed(ctx context.Context, id string) error
+}
+
+// MemorySeenStore is an in-memory SeenStore that forgets events after a TTL.
+type MemorySeenStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemorySeenStore returns a new *MemorySeenStore that remembers events for ttl.
+// The TTL should be longer than the period RevenueCat keeps retrying a delivery.
+func NewMemorySeenStore(ttl time.Duration) *MemorySeenStore {
+	return &MemorySeenStore{
+		ttl:  ttl,
+		now:  time.Now,
+		seen: make(map[string]time.Time),
+	}
+}
+
+func (s *MemorySeenStore) Seen(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.seen[id]
+	return ok && s.now().Sub(at) < s.ttl, nil
+}
+
+func (s *MemorySeenStore) MarkProcessed(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(id, s.now())
+	return nil
+}
+
+// add records id as processed at t and drops expired entries at most once per TTL. s.mu must be held.
+func (s *MemorySeenStore) add(id string, t time.Time) {
+	s.seen[id] = t
+	if t.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for k, at := range s.seen {
+		if t.Sub(at) >= s.ttl {
+			delete(s.seen, k)
+		}
+	}
+	s.lastSweep = t
+}
+
+// FileSeenStore is a SeenStore that persists processed event IDs to an append-only file,
+// so deduplication survives restarts. It is safe for use by a single process.
+type FileSeenStore struct {
+	mem  *MemorySeenStore
+	file *os.File
+}
+
+// NewFileSeenStore opens or creates the file at path and loads the events processed within ttl.
+// Expired entries are compacted away when the file is opened.
+func NewFileSeenStore(path string, ttl time.Duration) (*FileSeenStore, error) {
+	mem := NewMemorySeenStore(ttl)
+	if err := mem.load(path); err != nil {
+		return nil, err
+	}
+	if err := mem.compact(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening seen store: %v", err)
+	}
+	return &FileSeenStore{mem: mem, file: f}, nil
+}
+
+func (s *FileSeenStore) Seen(ctx context.Context, id string) (bool, error) {
+	return s.mem.Seen(ctx, id)
+}
+
+func (s *FileSeenStore) MarkProcessed(ctx context.Context, id string) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	now := s.mem.now()
+	if _, err := io.WriteString(s.file, seenLine(id, now)); err != nil {
+		return fmt.Errorf("error writing seen store: %v", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing seen store: %v", err)
+	}
+	s.mem.add(id, now)
+	return nil
+}
+
+// Close closes the underlying file.
+func (s *FileSeenStore) Close() error {
+	return s.file.Close()
+}
+
+// seenLine formats a "id<TAB>unix-ms" line. The ID is quoted so tabs and newlines in it can't corrupt the file.
+func seenLine(id string, at time.Time) string {
+	return strconv.Quote(id) + "\t" + strconv.FormatInt(at.UnixNano()/1e6, 10) + "\n"
+}
+
+// load reads "id<TAB>unix-ms" lines from path, skipping expired and malformed ones.
+// IDs are quoted, but unquoted IDs written by older versions are still read.
+func (s *MemorySeenStore) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening seen store: %v", err)
+	}
+	defer f.Close()
+
+	now := s.now()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		id := parts[0]
+		if strings.HasPrefix(id, `"`) {
+			if id, err = strconv.Unquote(id); err != nil {
+				continue
+			}
+		}
+		ms, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		at := time.Unix(0, ms*1e6)
+		if now.Sub(at) < s.ttl {
+			s.seen[id] = at
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading seen store: %v", err)
+	}
+	return nil
+}
+
+// compact rewrites path with only the entries currently held in memory.
+func (s *MemorySeenStore) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error compacting seen store: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	for id, at := range s.seen {
+		w.WriteString(seenLine(id, at))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("error compacting seen store: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error compacting seen store: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error compacting seen store: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemorySeenStoreTTL(t *testing.T) {
+	now := time.Unix(1579132457, 0)
+	s := NewMemorySeenStore(time.Hour)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if seen, _ := s.Seen(ctx, "a"); seen {
+		t.Error("expected a to be unseen")
+	}
+	s.MarkProcessed(ctx, "a")
+	if seen, _ := s.Seen(ctx, "a"); !seen {
+		t.Error("expected a to be seen")
+	}
+
+	now = now.Add(time.Hour)
+	if seen, _ := s.Seen(ctx, "a"); seen {
+		t.Error("expected a to have expired")
+	}
+	s.MarkProcessed(ctx, "b")
+	if _, ok := s.seen["a"]; ok {
+		t.Error("expected a to have been swept")
+	}
+}
+
+func TestFileSeenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	ctx := context.Background()
+
+	s, err := NewFileSeenStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := s.MarkProcessed(ctx, "a"); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	s, err = NewFileSeenStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer s.Close()
+	if seen, _ := s.Seen(ctx, "a"); !seen {
+		t.Error("expected a to be seen after reopening")
+	}
+	if seen, _ := s.Seen(ctx, "b"); seen {
+		t.Error("expected b to be unseen")
+	}
+}
+
+func TestFileSeenStoreDropsExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	ctx := context.Background()
+
+	s, err := NewFileSeenStore(path, time.Millisecond)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	s.MarkProcessed(ctx, "a")
+	s.Close()
+	time.Sleep(5 * time.Millisecond)
+
+	s, err = NewFileSeenStore(path, time.Millisecond)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer s.Close()
+	if len(s.mem.seen) != 0 {
+		t.Errorf("expected no entries, got: %v", s.mem.seen)
+	}
+}
+
+func TestFileSeenStoreEscapesIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	ctx := context.Background()
+	ids := []string{"a\tb", "c\nd", `"quoted"`}
+
+	s, err := NewFileSeenStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	for _, id := range ids {
+		if err := s.MarkProcessed(ctx, id); err != nil {
+			t.Fatalf("error: %v", err)
+		}
+	}
+	s.Close()
+
+	s, err = NewFileSeenStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer s.Close()
+	for _, id := range ids {
+		if seen, _ := s.Seen(ctx, id); !seen {
+			t.Errorf("expected %q to be seen after reopening", id)
+		}
+	}
+	if len(s.mem.seen) != len(ids) {
+		t.Errorf("expected %d entries, got: %q", len(ids), s.mem.seen)
+	}
+}
+
+func TestFileSeenStoreReadsUnquotedIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	line := "evt_1\t" + strconv.FormatInt(time.Now().UnixNano()/1e6, 10) + "\n"
+	if err := ioutil.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+
+	s, err := NewFileSeenStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	defer s.Close()
+	if seen, _ := s.Seen(context.Background(), "evt_1"); !seen {
+		t.Error("expected evt_1 to be seen")
+	}
+}